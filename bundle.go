@@ -0,0 +1,261 @@
+package mela
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Bundle is a .melarecipes file to be read, eg. an *os.File and its size.
+type Bundle struct {
+	R    io.ReaderAt
+	Size int64
+}
+
+// OpenBundle opens a .melarecipes file on disk as a Bundle, along with the file, which should be closed when done.
+func OpenBundle(filename string) (Bundle, io.Closer, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return Bundle{}, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return Bundle{}, nil, err
+	}
+	return Bundle{R: f, Size: info.Size()}, f, nil
+}
+
+// each calls fn for each of the bundle's recipes, one at a time, stopping at the first recipe that can't be parsed, or
+// that fn returns an error for.
+func (b Bundle) each(fn func(*Recipe) error) error {
+	var firstErr error
+	err := ParseRecipes(b.R, b.Size, func(r *Recipe, err error) {
+		if firstErr != nil {
+			return
+		}
+		if err != nil {
+			firstErr = fmt.Errorf("unable to parse recipe: %w", err)
+			return
+		}
+		firstErr = fn(r)
+	})
+	if err != nil {
+		return err
+	}
+	return firstErr
+}
+
+// DuplicatePolicy is how MergeBundles handles recipes that have the same ID.
+type DuplicatePolicy int
+
+const (
+	// KeepFirst keeps the first recipe with each ID, leaving out any later ones.
+	KeepFirst DuplicatePolicy = iota
+	// KeepLast keeps the last recipe with each ID, in the position of the last.
+	KeepLast
+	// KeepAll keeps every recipe.
+	KeepAll
+)
+
+// MergeBundles writes the recipes from each of the bundles, in order, to dst, handling recipes with the same ID
+// according to the policy. It returns the number of duplicates left out. Recipes are read one at a time, so bundles of
+// any size can be merged.
+func MergeBundles(dst *Recipes, policy DuplicatePolicy, bundles ...Bundle) (int, error) {
+	// For KeepLast, a first pass finds where the last of each ID is
+	last := make(map[string]int)
+	if policy == KeepLast {
+		n := 0
+		for i, b := range bundles {
+			err := b.each(func(r *Recipe) error {
+				last[r.ID] = n
+				n++
+				return nil
+			})
+			if err != nil {
+				return 0, fmt.Errorf("bundle %d: %w", i+1, err)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	n, dropped := 0, 0
+	for i, b := range bundles {
+		err := b.each(func(r *Recipe) error {
+			pos := n
+			n++
+
+			if r.ID != "" {
+				if (policy == KeepFirst && seen[r.ID]) || (policy == KeepLast && last[r.ID] != pos) {
+					dropped++
+					return nil
+				}
+				seen[r.ID] = true
+			}
+			return dst.Add(r)
+		})
+		if err != nil {
+			return dropped, fmt.Errorf("bundle %d: %w", i+1, err)
+		}
+	}
+	return dropped, nil
+}
+
+// SplitKey gives the groups a recipe belongs to, when splitting a bundle. The empty group holds recipes that don't
+// belong to any other.
+type SplitKey func(*Recipe) []string
+
+// SplitByCategory groups recipes by their categories; a recipe in many categories is in many groups.
+func SplitByCategory(r *Recipe) []string {
+	if len(r.Categories) == 0 {
+		return []string{""}
+	}
+	return r.Categories
+}
+
+// SplitBySource groups recipes by the website, or other source, that they're from.
+func SplitBySource(r *Recipe) []string {
+	if r.Link == "" {
+		return []string{""}
+	}
+	return []string{sourceName(r.Link)}
+}
+
+// SplitByBook groups recipes by the ISBN-13 of the book they're from.
+func SplitByBook(r *Recipe) []string {
+	if book := r.Book(); book != nil {
+		return []string{book.ISBN13}
+	}
+	return []string{""}
+}
+
+// SplitBundle writes each of the bundle's recipes to a bundle for each of the groups the key puts it in. The create
+// func is called to make the bundle for each group the first time it's needed, and all of them are closed when done.
+// Groups that would have the same filename (eg. "Breakfast" and "breakfast") are one group, named as it's first seen.
+func SplitBundle(b Bundle, key SplitKey, create func(group string) (*Recipes, error)) error {
+	groups := make(map[string]*Recipes)
+	err := b.each(func(r *Recipe) error {
+		added := make(map[string]bool)
+		for _, group := range key(r) {
+			id := stringToFilename(group)
+			if added[id] {
+				continue
+			}
+			added[id] = true
+
+			dst, ok := groups[id]
+			if !ok {
+				var err error
+				if dst, err = create(group); err != nil {
+					return fmt.Errorf("unable to create bundle for '%s': %w", group, err)
+				}
+				groups[id] = dst
+			}
+			if err := dst.Add(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	for _, dst := range groups {
+		if closeErr := dst.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// FilterBundle writes the bundle's recipes that keep returns true for to dst, returning how many were kept.
+func FilterBundle(b Bundle, dst *Recipes, keep func(*Recipe) bool) (int, error) {
+	kept := 0
+	err := b.each(func(r *Recipe) error {
+		if !keep(r) {
+			return nil
+		}
+		kept++
+		return dst.Add(r)
+	})
+	return kept, err
+}
+
+// RepackBundle rewrites a .melarecipes file in place, passing each recipe to transform (eg. to optimize its images)
+// before writing it back, reproducibly if asked (see Recipes.SetReproducible). The new bundle replaces the original only
+// once it's complete, so an error from transform, or anywhere else, leaves the original as it was.
+func RepackBundle(filename string, reproducible bool, transform func(*Recipe) error) error {
+	b, src, err := OpenBundle(filename)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp, err := createReplacement(filename)
+	if err != nil {
+		return err
+	}
+
+	dst := NewRecipesWriter(tmp)
+	if reproducible {
+		if err := dst.SetReproducible(); err != nil {
+			discardReplacement(tmp)
+			return err
+		}
+	}
+	err = b.each(func(r *Recipe) error {
+		if err := transform(r); err != nil {
+			return fmt.Errorf("unable to repack '%s': %w", r.Title, err)
+		}
+		return dst.Add(r)
+	})
+	if err == nil {
+		err = dst.Close()
+	}
+	if err != nil {
+		discardReplacement(tmp)
+		return err
+	}
+
+	src.Close()
+	return commitReplacement(tmp, filename)
+}
+
+// createReplacement creates a temporary file alongside filename, to be written then moved over it with
+// commitReplacement, so that readers only ever see the old or the new file complete.
+func createReplacement(filename string) (*os.File, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return nil, err
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode()
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		discardReplacement(tmp)
+		return nil, err
+	}
+	return tmp, nil
+}
+
+func commitReplacement(tmp *os.File, filename string) error {
+	if err := tmp.Sync(); err != nil {
+		discardReplacement(tmp)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func discardReplacement(tmp *os.File) {
+	tmp.Close()
+	os.Remove(tmp.Name())
+}
@@ -0,0 +1,244 @@
+package mela_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func openTestBundle(t *testing.T, filename string) mela.Bundle {
+	t.Helper()
+	b, f, err := mela.OpenBundle(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return b
+}
+
+func bundleContents(t *testing.T, data []byte) []*mela.Recipe {
+	t.Helper()
+	var recipes []*mela.Recipe
+	err := mela.ParseRecipes(bytes.NewReader(data), int64(len(data)), func(r *mela.Recipe, err error) {
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		recipes = append(recipes, r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return recipes
+}
+
+func recipeFilenames(recipes []*mela.Recipe) []string {
+	var names []string
+	for _, r := range recipes {
+		names = append(names, r.Filename)
+	}
+	return names
+}
+
+func TestMergeBundles(t *testing.T) {
+	data, err := os.ReadFile("fixtures/a+b.melarecipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ab := mela.Bundle{R: bytes.NewReader(data), Size: int64(len(data))}
+
+	// A bundle holding an edited copy of recipe a
+	recipes, err := mela.Open("fixtures/a.melarecipe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := recipes[0]
+	edited.Filename = "A title"
+	edited.Title = "A newer title"
+	buf := new(bytes.Buffer)
+	rs := mela.NewRecipesWriter(buf)
+	if err := rs.Add(edited); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	a := mela.Bundle{R: bytes.NewReader(buf.Bytes()), Size: int64(buf.Len())}
+
+	tests := []struct {
+		policy    mela.DuplicatePolicy
+		dropped   int
+		filenames []string
+		titles    []string
+	}{
+		{mela.KeepFirst, 1, []string{"B title", "A title"}, []string{"B title", "A title"}},
+		{mela.KeepLast, 1, []string{"B title", "A title"}, []string{"B title", "A newer title"}},
+		{mela.KeepAll, 0, []string{"B title", "A title", "A title-2"}, []string{"B title", "A title", "A newer title"}},
+	}
+	for _, tt := range tests {
+		out := new(bytes.Buffer)
+		dst := mela.NewRecipesWriter(out)
+		dropped, err := mela.MergeBundles(dst, tt.policy, ab, a)
+		if err != nil {
+			t.Fatalf("For policy %d, unable to merge: %v", tt.policy, err)
+		}
+		if err := dst.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if dropped != tt.dropped {
+			t.Errorf("For policy %d, wanted %d duplicates, got %d", tt.policy, tt.dropped, dropped)
+		}
+		got := bundleContents(t, out.Bytes())
+		if names := recipeFilenames(got); !reflect.DeepEqual(names, tt.filenames) {
+			t.Errorf("For policy %d, incorrect files: want = %v, got = %v", tt.policy, tt.filenames, names)
+		}
+		var titles []string
+		for _, r := range got {
+			titles = append(titles, r.Title)
+		}
+		if !reflect.DeepEqual(titles, tt.titles) {
+			t.Errorf("For policy %d, incorrect recipes: want = %v, got = %v", tt.policy, tt.titles, titles)
+		}
+	}
+}
+
+func TestSplitBundle(t *testing.T) {
+	b := openTestBundle(t, "fixtures/a+b.melarecipes")
+
+	outputs := make(map[string]*bytes.Buffer)
+	err := mela.SplitBundle(b, func(r *mela.Recipe) []string { return []string{r.ID} }, func(group string) (*mela.Recipes, error) {
+		outputs[group] = new(bytes.Buffer)
+		return mela.NewRecipesWriter(outputs[group]), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(outputs) != 2 {
+		t.Fatalf("Wanted 2 bundles, got %d", len(outputs))
+	}
+	for id, out := range outputs {
+		got := bundleContents(t, out.Bytes())
+		if len(got) != 1 || got[0].ID != id {
+			t.Errorf("Bundle %s should only hold recipe %s", id, id)
+		}
+	}
+}
+
+func TestSplitBundle_SameFilename(t *testing.T) {
+	b := openTestBundle(t, "fixtures/a+b.melarecipes")
+
+	outputs := make(map[string]*bytes.Buffer)
+	key := func(r *mela.Recipe) []string { return []string{"Breakfast", "breakfast", "Sweet", "Sweet"} }
+	err := mela.SplitBundle(b, key, func(group string) (*mela.Recipes, error) {
+		if _, ok := outputs[group]; ok {
+			t.Errorf("Bundle for %s created twice", group)
+		}
+		outputs[group] = new(bytes.Buffer)
+		return mela.NewRecipesWriter(outputs[group]), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(outputs) != 2 || outputs["Breakfast"] == nil || outputs["Sweet"] == nil {
+		t.Fatalf("Wanted bundles for Breakfast and Sweet, got %v", outputs)
+	}
+	for group, out := range outputs {
+		if got := bundleContents(t, out.Bytes()); len(got) != 2 {
+			t.Errorf("Bundle %s should hold each recipe once, got %v", group, recipeFilenames(got))
+		}
+	}
+}
+
+func TestSplitKeys(t *testing.T) {
+	r := &mela.Recipe{Link: "https://www.example.com/pancakes", Categories: []string{"Breakfast", "Sweet"}}
+	if got := mela.SplitByCategory(r); !reflect.DeepEqual(got, []string{"Breakfast", "Sweet"}) {
+		t.Errorf("Incorrect categories: %v", got)
+	}
+	if got := mela.SplitBySource(r); !reflect.DeepEqual(got, []string{"www.example.com"}) {
+		t.Errorf("Incorrect source: %v", got)
+	}
+	if got := mela.SplitByBook(r); !reflect.DeepEqual(got, []string{""}) {
+		t.Errorf("Incorrect book: %v", got)
+	}
+
+	if err := r.SetBook("123456789X", nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := mela.SplitByBook(r); !reflect.DeepEqual(got, []string{"9781234567897"}) {
+		t.Errorf("Incorrect book: %v", got)
+	}
+}
+
+func TestFilterBundle(t *testing.T) {
+	b := openTestBundle(t, "fixtures/a+b.melarecipes")
+
+	out := new(bytes.Buffer)
+	dst := mela.NewRecipesWriter(out)
+	kept, err := mela.FilterBundle(b, dst, func(r *mela.Recipe) bool { return r.ID == "a" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if kept != 1 {
+		t.Errorf("Wanted 1 recipe kept, got %d", kept)
+	}
+	if got := bundleContents(t, out.Bytes()); len(got) != 1 || got[0].ID != "a" {
+		t.Errorf("Incorrect recipes kept: %v", recipeFilenames(got))
+	}
+}
+
+func TestRepackBundle(t *testing.T) {
+	data, err := os.ReadFile("fixtures/a+b.melarecipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "repack.melarecipes")
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = mela.RepackBundle(filename, false, func(r *mela.Recipe) error {
+		r.Title += " (repacked)"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repacked, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a": "A title (repacked)", "b": "B title (repacked)"}
+	for _, r := range bundleContents(t, repacked) {
+		if r.Title != want[r.ID] {
+			t.Errorf("Recipe %s wasn't repacked: %s", r.ID, r.Title)
+		}
+	}
+
+	errStop := errors.New("stop")
+	if err := mela.RepackBundle(filename, false, func(r *mela.Recipe) error { return errStop }); !errors.Is(err, errStop) {
+		t.Errorf("Expected the transform's error, got %v", err)
+	}
+	unchanged, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unchanged, repacked) {
+		t.Error("A failed repack changed the bundle")
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(filename)); len(entries) != 1 {
+		t.Errorf("A failed repack left %d files behind", len(entries)-1)
+	}
+}
@@ -0,0 +1,279 @@
+package mela
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// CanonicalImageDir is the folder, alongside canonical recipe files, that SaveCanonical keeps image files in.
+const CanonicalImageDir = "images"
+
+const canonicalExt = ".json"
+const canonicalSidecarExt = ".images"
+
+// canonicalRecipe is the git-friendly form of a Recipe. Its fields are in alphabetical order, so they're always written
+// in the same order, and multi-line fields are lists of lines, so a change to one line is a change to one line of the
+// file.
+type canonicalRecipe struct {
+	Categories   []string       `json:"categories"`
+	CookTime     MaybeDuration  `json:"cookTime"`
+	Date         AppleDate      `json:"date,omitempty"`
+	Favorite     bool           `json:"favorite"`
+	ID           string         `json:"id"`
+	Images       []B64Image     `json:"images,omitempty"`
+	Ingredients  canonicalLines `json:"ingredients"`
+	Instructions canonicalLines `json:"instructions"`
+	Link         string         `json:"link"`
+	Notes        canonicalLines `json:"notes"`
+	Nutrition    canonicalLines `json:"nutrition"`
+	PrepTime     MaybeDuration  `json:"prepTime"`
+	Text         canonicalLines `json:"text"`
+	Title        string         `json:"title"`
+	TotalTime    MaybeDuration  `json:"totalTime"`
+	WantToCook   bool           `json:"wantToCook"`
+	Yield        PeopleCount    `json:"yield"`
+}
+
+// canonicalLines is a multi-line string, written as a list of its lines. It can be read from a plain string too, so
+// standard .melarecipe files are valid canonical files.
+type canonicalLines []string
+
+func newCanonicalLines(str string) canonicalLines {
+	if str == "" {
+		return canonicalLines{}
+	}
+	return strings.Split(str, "\n")
+}
+
+func (cl canonicalLines) String() string {
+	return strings.Join(cl, "\n")
+}
+
+func (cl *canonicalLines) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*cl = newCanonicalLines(str)
+		return nil
+	}
+
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return errors.New("expected a string or a list of lines")
+	}
+	*cl = lines
+	return nil
+}
+
+func toCanonical(r *Recipe) canonicalRecipe {
+	cr := canonicalRecipe{
+		Categories:   r.Categories,
+		CookTime:     r.CookTime,
+		Date:         r.Date,
+		Favorite:     r.Favorite,
+		ID:           r.ID,
+		Images:       r.Images,
+		Ingredients:  newCanonicalLines(string(r.Ingredients)),
+		Instructions: newCanonicalLines(string(r.Instructions)),
+		Link:         r.Link,
+		Notes:        newCanonicalLines(r.Notes),
+		Nutrition:    newCanonicalLines(r.Nutrition),
+		PrepTime:     r.PrepTime,
+		Text:         newCanonicalLines(r.Text),
+		Title:        r.Title,
+		TotalTime:    r.TotalTime,
+		WantToCook:   r.WantToCook,
+		Yield:        r.Yield,
+	}
+	if cr.Categories == nil {
+		cr.Categories = make([]string, 0)
+	}
+	return cr
+}
+
+func (cr canonicalRecipe) toRecipe() *Recipe {
+	r := &Recipe{
+		ID:           cr.ID,
+		Title:        cr.Title,
+		Link:         cr.Link,
+		Text:         cr.Text.String(),
+		Ingredients:  SectionedSequence(cr.Ingredients.String()),
+		Instructions: SectionedSequence(cr.Instructions.String()),
+		Nutrition:    cr.Nutrition.String(),
+		Categories:   cr.Categories,
+		Notes:        cr.Notes.String(),
+		Images:       cr.Images,
+		Yield:        cr.Yield,
+		PrepTime:     cr.PrepTime,
+		CookTime:     cr.CookTime,
+		TotalTime:    cr.TotalTime,
+		Favorite:     cr.Favorite,
+		WantToCook:   cr.WantToCook,
+		Date:         cr.Date,
+	}
+	if r.Categories == nil {
+		r.Categories = make([]string, 0)
+	}
+	if r.Images == nil {
+		r.Images = make([]B64Image, 0)
+	}
+	return r
+}
+
+// MarshalCanonical encodes the recipe in a canonical, git-friendly form: indented JSON with its keys in a stable order,
+// and its multi-line fields (text, ingredients, instructions, nutrition and notes) as lists of lines. Images are
+// included as base64, use SaveCanonical to keep them in separate files.
+func (r *Recipe) MarshalCanonical() ([]byte, error) {
+	return marshalCanonical(toCanonical(r))
+}
+
+func marshalCanonical(cr canonicalRecipe) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(cr); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseCanonical decodes a recipe written by MarshalCanonical (or a standard .melarecipe file).
+func ParseCanonical(r io.Reader) (*Recipe, error) {
+	var cr canonicalRecipe
+	if err := json.NewDecoder(r).Decode(&cr); err != nil {
+		return nil, err
+	}
+	return cr.toRecipe(), nil
+}
+
+// SaveCanonical writes the recipe in its canonical form (see MarshalCanonical) to a .json file in the given directory,
+// and returns its path. With imageFiles, each image is instead written to the images folder within dir, named by the
+// SHA-256 hash of its contents (so recipes sharing an image share the file, and unchanged images are never rewritten),
+// and the recipe's images are listed in a sidecar .images file, one path per line.
+func (r *Recipe) SaveCanonical(dir string, imageFiles bool) (string, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return "", fmt.Errorf("output directory '%s' does not exist", dir)
+	}
+
+	name := r.Filename
+	if name == "" {
+		name = stringToFilename(r.Title)
+	}
+	name = filepath.Base(name)
+
+	cr := toCanonical(r)
+	sidecar := filepath.Join(dir, name+canonicalSidecarExt)
+	if imageFiles {
+		cr.Images = nil
+
+		var paths []string
+		for _, img := range r.Images {
+			imagePath, err := saveCanonicalImage(dir, img)
+			if err != nil {
+				return "", fmt.Errorf("unable to write image file: %w", err)
+			}
+			paths = append(paths, imagePath+"\n")
+		}
+		if err := os.WriteFile(sidecar, []byte(strings.Join(paths, "")), 0644); err != nil {
+			return "", fmt.Errorf("unable to write image list: %w", err)
+		}
+	} else if err := os.Remove(sidecar); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("unable to remove image list: %w", err)
+	}
+
+	data, err := marshalCanonical(cr)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal recipe: %w", err)
+	}
+
+	destination := filepath.Join(dir, name+canonicalExt)
+	if err := os.WriteFile(destination, data, 0644); err != nil {
+		return "", fmt.Errorf("unable to write recipe file: %w", err)
+	}
+
+	return destination, nil
+}
+
+// saveCanonicalImage writes the image to its content-addressed path within dir, unless it's already there, and returns
+// that path, relative to dir.
+func saveCanonicalImage(dir string, img B64Image) (string, error) {
+	sum := sha256.Sum256(img)
+	imagePath := path.Join(CanonicalImageDir, hex.EncodeToString(sum[:])+imageExtension(img))
+
+	dest := filepath.Join(dir, filepath.FromSlash(imagePath))
+	if _, err := os.Stat(dest); err == nil {
+		return imagePath, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+	return imagePath, os.WriteFile(dest, img, 0644)
+}
+
+// OpenCanonical reads a recipe saved by SaveCanonical, loading its images from the files listed in its sidecar .images
+// file, if it has one. The result can be saved as a standard .melarecipe file with Save.
+func OpenCanonical(filename string) (*Recipe, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := ParseCanonical(f)
+	if err != nil {
+		return nil, err
+	}
+	r.Filename = withoutExt(filepath.Base(filename))
+
+	dir := filepath.Dir(filename)
+	sidecar, err := os.Open(filepath.Join(dir, r.Filename+canonicalSidecarExt))
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer sidecar.Close()
+
+	images, err := readCanonicalImages(sidecar, os.DirFS(dir))
+	if err != nil {
+		return nil, err
+	}
+	r.Images = append(r.Images, images...)
+	return r, nil
+}
+
+// readCanonicalImages loads each of the images listed in a sidecar file, checking they haven't changed since they were
+// named.
+func readCanonicalImages(sidecar io.Reader, fsys fs.FS) ([]B64Image, error) {
+	var images []B64Image
+	scanner := bufio.NewScanner(sidecar)
+	for scanner.Scan() {
+		imagePath := strings.TrimSpace(scanner.Text())
+		if imagePath == "" {
+			continue
+		}
+
+		img, err := fs.ReadFile(fsys, imagePath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read image: %w", err)
+		}
+		sum := sha256.Sum256(img)
+		if hash := withoutExt(path.Base(imagePath)); hash != hex.EncodeToString(sum[:]) {
+			return nil, fmt.Errorf("image '%s' doesn't match its hash", imagePath)
+		}
+		images = append(images, img)
+	}
+	return images, scanner.Err()
+}
@@ -0,0 +1,153 @@
+package mela_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func TestRecipe_MarshalCanonical(t *testing.T) {
+	r := &mela.Recipe{
+		ID:           "example.com/toast",
+		Title:        "Toast & jam",
+		Ingredients:  "1 slice bread\n# Topping\nJam",
+		Instructions: "Toast the bread.\nSpread with jam.",
+		Categories:   []string{"breakfast"},
+		Yield:        "1",
+		Images:       []mela.B64Image{},
+	}
+
+	got, err := r.MarshalCanonical()
+	if err != nil {
+		t.Fatalf("Unable to marshal canonical recipe: %v", err)
+	}
+
+	want := `{
+  "categories": [
+    "breakfast"
+  ],
+  "cookTime": "",
+  "favorite": false,
+  "id": "example.com/toast",
+  "ingredients": [
+    "1 slice bread",
+    "# Topping",
+    "Jam"
+  ],
+  "instructions": [
+    "Toast the bread.",
+    "Spread with jam."
+  ],
+  "link": "",
+  "notes": [],
+  "nutrition": [],
+  "prepTime": "",
+  "text": [],
+  "title": "Toast & jam",
+  "totalTime": "",
+  "wantToCook": false,
+  "yield": "1"
+}
+`
+	if string(got) != want {
+		t.Errorf("Incorrect canonical recipe:\nwant = %s\ngot  = %s", want, got)
+	}
+}
+
+func TestParseCanonical(t *testing.T) {
+	for _, fixture := range []string{"a", "b", "c"} {
+		recipes, err := mela.Open("fixtures/" + fixture + ".melarecipe")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := recipes[0]
+		want.Filename = ""
+
+		data, err := want.MarshalCanonical()
+		if err != nil {
+			t.Fatalf("For %s, unable to marshal: %v", fixture, err)
+		}
+		got, err := mela.ParseCanonical(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("For %s, unable to parse: %v", fixture, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("For %s, recipe changed in round trip:\nwant = %#v\ngot  = %#v", fixture, want, got)
+		}
+
+		again, err := got.MarshalCanonical()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(again, data) {
+			t.Errorf("For %s, canonical form isn't stable", fixture)
+		}
+	}
+}
+
+func TestParseCanonical_Standard(t *testing.T) {
+	f, err := os.Open("fixtures/a.melarecipe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := mela.ParseCanonical(f)
+	if err != nil {
+		t.Fatalf("Unable to parse standard recipe: %v", err)
+	}
+	EnsureRecipe(t, got, "a")
+}
+
+func TestRecipe_SaveCanonical(t *testing.T) {
+	recipes, err := mela.Open("fixtures/a+b.melarecipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	for _, want := range recipes {
+		dest, err := want.SaveCanonical(dir, true)
+		if err != nil {
+			t.Fatalf("Unable to save %s: %v", want.ID, err)
+		}
+
+		data, err := os.ReadFile(dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, []byte(`"images"`)) {
+			t.Errorf("For %s, images were written inline", want.ID)
+		}
+
+		got, err := mela.OpenCanonical(dest)
+		if err != nil {
+			t.Fatalf("Unable to open %s: %v", dest, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("For %s, recipe changed in round trip", want.ID)
+		}
+	}
+
+	imageFiles, err := os.ReadDir(filepath.Join(dir, mela.CanonicalImageDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both recipes have the same image
+	if len(imageFiles) != 1 {
+		t.Errorf("Expected 1 shared image file, got %d", len(imageFiles))
+	}
+
+	sidecar, err := os.ReadFile(filepath.Join(dir, recipes[1].Filename+".images"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(sidecar)), "\n"); len(lines) != len(recipes[1].Images) || !strings.HasPrefix(lines[0], "images/") {
+		t.Errorf("Incorrect image list: %q", sidecar)
+	}
+}
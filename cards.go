@@ -0,0 +1,361 @@
+package mela
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// CardSize is the size of the cards (or pages) recipes are printed on, in points (1/72 inch).
+type CardSize struct {
+	Name          string
+	Width, Height float64
+	// FontSize is the size of the body text, from which the other text sizes are derived
+	FontSize float64
+}
+
+var (
+	CardA4     = CardSize{"A4", 595.28, 841.89, 10}
+	CardLetter = CardSize{"Letter", 612, 792, 10}
+	// Index cards are printed landscape
+	Card4x6 = CardSize{"4x6", 432, 288, 7}
+	Card5x7 = CardSize{"5x7", 504, 360, 7.5}
+)
+
+// CardSizes are the sizes recipe cards can be printed on.
+var CardSizes = []CardSize{CardA4, CardLetter, Card4x6, Card5x7}
+
+// LookupCardSize finds the card size with the given name, eg. "4x6".
+func LookupCardSize(name string) (CardSize, bool) {
+	for _, s := range CardSizes {
+		if strings.EqualFold(s.Name, name) {
+			return s, true
+		}
+	}
+	return CardSize{}, false
+}
+
+// WriteCards writes the recipe as a PDF of printable cards. See WriteCardsPDF.
+func (r *Recipe) WriteCards(w io.Writer, size CardSize) error {
+	return WriteCardsPDF(w, []*Recipe{r}, size)
+}
+
+// WriteCardsPDF lays out each recipe on cards of the given size, with its title, yield and times, its first image,
+// its ingredients in two columns and its numbered instructions. Recipes too long for one card continue onto more,
+// and each recipe starts on a new card.
+func WriteCardsPDF(w io.Writer, recipes []*Recipe, size CardSize) error {
+	if len(recipes) == 0 {
+		return ErrNoRecipes
+	}
+
+	var pages []*pdfPage
+	for _, r := range recipes {
+		l := newCardLayout(r, size)
+		l.layout()
+		pages = append(pages, l.pages...)
+	}
+
+	title := recipes[0].Title
+	if len(recipes) > 1 {
+		title = fmt.Sprintf("%d recipes", len(recipes))
+	}
+	return writePDF(w, pages, size.Width, size.Height, title)
+}
+
+// SaveCards writes the recipes as a PDF of printable cards to the given file. See WriteCardsPDF.
+func SaveCards(filename string, recipes []*Recipe, size CardSize) error {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if err := WriteCardsPDF(f, recipes, size); err != nil {
+		f.Close()
+		os.Remove(filename)
+		return err
+	}
+	return f.Close()
+}
+
+// cardRow is a single line of text on a card.
+type cardRow struct {
+	text   string
+	font   pdfFont
+	indent float64
+}
+
+// cardBlock is a run of rows that are kept together in a column where possible, eg. a wrapped ingredient line.
+type cardBlock []cardRow
+
+type cardLayout struct {
+	recipe *Recipe
+	size   CardSize
+	pages  []*pdfPage
+	page   *pdfPage
+
+	margin, gap      float64
+	body, small      float64
+	leading          float64
+	left, right, top float64
+	// y is the top of the next line to be written
+	y float64
+}
+
+func newCardLayout(r *Recipe, size CardSize) *cardLayout {
+	margin := min(size.Width, size.Height) * 0.06
+	return &cardLayout{
+		recipe:  r,
+		size:    size,
+		margin:  margin,
+		gap:     size.FontSize,
+		body:    size.FontSize,
+		small:   size.FontSize * 0.85,
+		leading: size.FontSize * 1.3,
+		left:    margin,
+		right:   size.Width - margin,
+		top:     size.Height - margin,
+	}
+}
+
+func (l *cardLayout) width() float64 { return l.right - l.left }
+
+func (l *cardLayout) layout() {
+	l.newPage()
+	l.header()
+
+	l.ingredients()
+	l.instructions()
+	l.notes()
+
+	if len(l.pages) > 1 {
+		for i, p := range l.pages {
+			label := fmt.Sprintf("%d/%d", i+1, len(l.pages))
+			p.text(l.right-pdfTextWidth(label, pdfRegular, l.small), l.margin/2, label, pdfRegular, l.small, 0.4)
+		}
+	}
+}
+
+func (l *cardLayout) newPage() {
+	l.page = &pdfPage{}
+	l.pages = append(l.pages, l.page)
+	l.y = l.top
+
+	if len(l.pages) > 1 {
+		l.row(cardRow{text: l.recipe.Title + " (continued)", font: pdfBold}, l.left, l.small, 0.4)
+		l.y -= l.gap / 2
+	}
+}
+
+// fits reports whether height more points fit on the current card.
+func (l *cardLayout) fits(height float64) bool {
+	return l.y-height >= l.margin
+}
+
+// row writes a line of text at the cursor, moving it down a line.
+func (l *cardLayout) row(r cardRow, x, size, gray float64) {
+	l.page.text(x+r.indent, l.y-size, r.text, r.font, size, gray)
+	l.y -= size * 1.3
+}
+
+// header writes the title, details and photo at the top of the first card.
+func (l *cardLayout) header() {
+	textRight := l.right
+	photoBottom := l.top
+
+	if len(l.recipe.Images) > 0 {
+		photo, _ := l.recipe.Images[0].OptimizeWithConfig(1024, 1024)
+		if img, err := newPDFImage(photo); err == nil {
+			w := l.width() * 0.35
+			h := w * float64(img.height) / float64(img.width)
+			if maxH := (l.top - l.margin) * 0.4; h > maxH {
+				h, w = maxH, maxH*float64(img.width)/float64(img.height)
+			}
+			l.page.image(img, l.right-w, l.top-h, w, h)
+			textRight = l.right - w - l.gap
+			photoBottom = l.top - h
+		}
+	}
+
+	titleSize := l.body * 1.8
+	for _, line := range pdfWrap(l.recipe.Title, pdfBold, titleSize, textRight-l.left) {
+		l.row(cardRow{text: line, font: pdfBold}, l.left, titleSize, 0)
+	}
+
+	var details []string
+	for _, d := range (HTMLRecipePage{Recipe: l.recipe}).Details() {
+		details = append(details, d.Label+": "+d.Value)
+	}
+	if l.recipe.Link != "" && l.recipe.Book() == nil {
+		details = append(details, sourceTitle(l.recipe.Link))
+	}
+	for _, line := range pdfWrap(strings.Join(details, " · "), pdfRegular, l.small, textRight-l.left) {
+		l.row(cardRow{text: line}, l.left, l.small, 0.35)
+	}
+
+	l.y = min(l.y, photoBottom) - l.gap
+}
+
+// heading starts a new part of the recipe, moving to a new card if there isn't room for it and a line beneath.
+func (l *cardLayout) heading(text string) {
+	size := l.body * 1.15
+	if !l.fits(size*1.3 + l.leading*2) {
+		l.newPage()
+	}
+	l.row(cardRow{text: text, font: pdfBold}, l.left, size, 0)
+	l.page.line(l.left, l.y+size*0.15, l.right, l.y+size*0.15, 0.7)
+	l.y -= l.gap / 3
+}
+
+func (l *cardLayout) ingredients() {
+	sections := l.recipe.Ingredients.Sections()
+	if len(sections) == 0 {
+		return
+	}
+	l.heading("Ingredients")
+
+	colWidth := (l.width() - l.gap) / 2
+	var blocks []cardBlock
+	for _, s := range sections {
+		var heading cardBlock
+		if s.Heading != "" {
+			heading = cardBlock{{text: s.Heading, font: pdfBold}}
+		}
+		for _, line := range s.Lines {
+			// Headings are kept with the first line beneath them
+			block := heading
+			heading = nil
+			for i, text := range pdfWrap(line, pdfRegular, l.body, colWidth-l.body) {
+				row := cardRow{text: text}
+				if i > 0 {
+					row.indent = l.body
+				}
+				block = append(block, row)
+			}
+			blocks = append(blocks, block)
+		}
+	}
+
+	height := func(bs []cardBlock) float64 {
+		h := 0.0
+		for _, b := range bs {
+			h += float64(len(b)) * l.leading
+		}
+		return h
+	}
+	// fitting counts how many blocks fill a column of the given height
+	fitting := func(bs []cardBlock, avail float64) int {
+		n, h := 0, 0.0
+		for _, b := range bs {
+			if h += float64(len(b)) * l.leading; h > avail {
+				break
+			}
+			n++
+		}
+		return n
+	}
+
+	for len(blocks) > 0 {
+		avail := l.y - l.margin
+		leftN := fitting(blocks, avail)
+		if leftN == 0 {
+			if l.y < l.top-l.leading*3 {
+				l.newPage()
+				continue
+			}
+			// Taller than a whole card, so it will overflow regardless
+			leftN = 1
+		}
+		rightN := fitting(blocks[leftN:], avail)
+
+		if leftN+rightN == len(blocks) {
+			// Everything fits, so balance the columns
+			total := height(blocks)
+			leftN = 0
+			for leftN < len(blocks) && height(blocks[:leftN+1]) <= total/2 {
+				leftN++
+			}
+			for leftN < len(blocks) && height(blocks[leftN:]) > avail {
+				leftN++
+			}
+			rightN = len(blocks) - leftN
+		}
+
+		startY := l.y
+		for _, b := range blocks[:leftN] {
+			for _, r := range b {
+				l.row(r, l.left, l.body, 0)
+			}
+		}
+		leftY := l.y
+		l.y = startY
+		for _, b := range blocks[leftN : leftN+rightN] {
+			for _, r := range b {
+				l.row(r, l.left+colWidth+l.gap, l.body, 0)
+			}
+		}
+		l.y = min(l.y, leftY)
+
+		blocks = blocks[leftN+rightN:]
+		if len(blocks) > 0 {
+			l.newPage()
+		}
+	}
+
+	l.y -= l.gap / 2
+}
+
+func (l *cardLayout) instructions() {
+	sections := l.recipe.Instructions.Sections()
+	if len(sections) == 0 {
+		return
+	}
+	l.heading("Instructions")
+
+	numWidth := pdfTextWidth("00. ", pdfBold, l.body)
+	for _, s := range sections {
+		if s.Heading != "" {
+			if !l.fits(l.leading * 2) {
+				l.newPage()
+			}
+			l.row(cardRow{text: s.Heading, font: pdfBold}, l.left, l.body, 0)
+		}
+
+		for i, step := range s.Lines {
+			lines := pdfWrap(step, pdfRegular, l.body, l.width()-numWidth)
+			// Short steps are kept together on one card
+			if !l.fits(float64(len(lines))*l.leading) && len(lines) <= 4 {
+				l.newPage()
+			}
+
+			for j, line := range lines {
+				if !l.fits(l.leading) {
+					l.newPage()
+				}
+				if j == 0 {
+					l.page.text(l.left, l.y-l.body, fmt.Sprintf("%d.", i+1), pdfBold, l.body, 0)
+				}
+				l.row(cardRow{text: line, indent: numWidth}, l.left, l.body, 0)
+			}
+			l.y -= l.leading * 0.3
+		}
+	}
+
+	l.y -= l.gap / 2
+}
+
+func (l *cardLayout) notes() {
+	if strings.TrimSpace(l.recipe.Notes) == "" {
+		return
+	}
+	l.heading("Notes")
+
+	for _, para := range strings.Split(l.recipe.Notes, "\n") {
+		for _, line := range pdfWrap(para, pdfRegular, l.small, l.width()) {
+			if !l.fits(l.small * 1.3) {
+				l.newPage()
+			}
+			l.row(cardRow{text: line}, l.left, l.small, 0.2)
+		}
+	}
+}
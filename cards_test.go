@@ -0,0 +1,128 @@
+package mela_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+var pdfXref = regexp.MustCompile(`(?s)xref\n0 (\d+)\n(.*?)trailer`)
+var pdfPageCount = regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`)
+var pdfStream = regexp.MustCompile(`(?s)/Filter /FlateDecode /Length (\d+) >>\nstream\n`)
+
+// checkPDF verifies the PDF's cross-reference table, and returns its page count and the text of its pages.
+func checkPDF(t *testing.T, data []byte) (int, string) {
+	t.Helper()
+
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("Not a PDF file")
+	}
+
+	m := pdfXref.FindSubmatch(data)
+	if m == nil {
+		t.Fatal("PDF has no cross-reference table")
+	}
+	for i, entry := range strings.Split(strings.TrimSpace(string(m[2])), "\n")[1:] {
+		offset, err := strconv.Atoi(entry[:10])
+		if err != nil {
+			t.Fatalf("Invalid xref entry %q", entry)
+		}
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Errorf("Object %d isn't at offset %d", i+1, offset)
+		}
+	}
+
+	pc := pdfPageCount.FindSubmatch(data)
+	if pc == nil {
+		t.Fatal("PDF has no page tree")
+	}
+	pages, _ := strconv.Atoi(string(pc[1]))
+
+	var text strings.Builder
+	for _, loc := range pdfStream.FindAllSubmatchIndex(data, -1) {
+		length, _ := strconv.Atoi(string(data[loc[2]:loc[3]]))
+		zr, err := zlib.NewReader(bytes.NewReader(data[loc[1] : loc[1]+length]))
+		if err != nil {
+			t.Fatalf("Invalid content stream: %v", err)
+		}
+		content, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("Invalid content stream: %v", err)
+		}
+		text.Write(content)
+	}
+
+	return pages, text.String()
+}
+
+func TestWriteCardsPDF(t *testing.T) {
+	recipes, err := mela.Open("fixtures/a+b.melarecipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range mela.CardSizes {
+		t.Run(size.Name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := mela.WriteCardsPDF(&buf, recipes, size); err != nil {
+				t.Fatalf("Unable to write cards: %v", err)
+			}
+
+			pages, text := checkPDF(t, buf.Bytes())
+			if pages != 2 {
+				t.Errorf("Incorrect number of cards: want = 2, got = %d", pages)
+			}
+			for _, want := range []string{"(A title) Tj", "(B ingredients) Tj", "(Ingredients) Tj", "(1.) Tj", "/Im1 Do"} {
+				if !strings.Contains(text, want) {
+					t.Errorf("Cards don't contain %q", want)
+				}
+			}
+		})
+	}
+}
+
+func TestRecipe_WriteCards_Overflow(t *testing.T) {
+	var ingredients, steps []string
+	for i := 1; i <= 30; i++ {
+		ingredients = append(ingredients, fmt.Sprintf("%dg ingredient number %d", i*10, i))
+		steps = append(steps, fmt.Sprintf("Do the %d(th) thing, carefully and slowly, until it is done to your liking.", i))
+	}
+	r := &mela.Recipe{
+		Title:        "Crème brûlée, the long way",
+		Yield:        "4",
+		TotalTime:    "2 hrs",
+		Ingredients:  mela.SectionedSequence("# Custard\n" + strings.Join(ingredients, "\n")),
+		Instructions: mela.SectionedSequence(strings.Join(steps, "\n")),
+		Notes:        "Best made the day before.",
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteCards(&buf, mela.Card4x6); err != nil {
+		t.Fatalf("Unable to write cards: %v", err)
+	}
+
+	pages, text := checkPDF(t, buf.Bytes())
+	if pages < 3 {
+		t.Errorf("Expected the recipe to overflow onto several cards, got %d", pages)
+	}
+	for _, want := range []string{
+		"(Cr\xe8me br\xfbl\xe9e, the long way) Tj",
+		"(Cr\xe8me br\xfbl\xe9e, the long way \\(continued\\)) Tj",
+		"(300g ingredient number 30) Tj",
+		"(30.) Tj",
+		"(Custard) Tj",
+		"(Best made the day before.) Tj",
+		fmt.Sprintf("(%d/%d) Tj", pages, pages),
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Cards don't contain %q", want)
+		}
+	}
+}
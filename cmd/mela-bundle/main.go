@@ -0,0 +1,275 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+var (
+	version = "0.0.0"
+	commit  = "dev"
+	date    = time.Now().Format(time.DateOnly)
+)
+
+var commands = map[string]func(execName string, args []string){
+	"merge":  merge,
+	"split":  split,
+	"filter": filter,
+	"repack": repack,
+}
+
+func main() {
+	execName := filepath.Base(os.Args[0])
+	if len(os.Args) < 2 || commands[os.Args[1]] == nil {
+		fmt.Printf(
+			"Mela Bundle v%s-%s (%s)\n\nUsage: %s <command> [options] <arguments>\n\nCommands:\n"+
+				"  merge   combine .melarecipes files into one\n"+
+				"  split   split a .melarecipes file by category, source or book\n"+
+				"  filter  copy the recipes that match from a .melarecipes file\n"+
+				"  repack  rewrite a .melarecipes file in place, eg. to optimize its images\n\n"+
+				"Use '%s <command> -h' for a command's options.\n",
+			version, commit, date, execName, execName)
+		os.Exit(1)
+	}
+
+	commands[os.Args[1]](execName, os.Args[2:])
+}
+
+func flagSet(execName, command, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Printf(
+			"Mela Bundle v%s-%s (%s)\n\nUsage: %s %s [options] %s\n\nOptions:\n",
+			version, commit, date, execName, command, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func merge(execName string, args []string) {
+	fs := flagSet(execName, "merge", "<.melarecipes> [...<.melarecipes>] <output .melarecipes>")
+	dups := fs.String("duplicates", "first", "which recipe to keep when several have the same ID: 'first', 'last' or 'all'")
+	reproducible := reproducibleFlag(fs)
+	_ = fs.Parse(args)
+
+	policies := map[string]mela.DuplicatePolicy{"first": mela.KeepFirst, "last": mela.KeepLast, "all": mela.KeepAll}
+	policy, ok := policies[*dups]
+	if !ok {
+		fail("Unknown duplicate handling '%s', use 'first', 'last' or 'all'\n", *dups)
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	inputFiles := fs.Args()[:fs.NArg()-1]
+	outputFile := fs.Arg(fs.NArg() - 1)
+
+	var bundles []mela.Bundle
+	for _, file := range inputFiles {
+		b, f, err := mela.OpenBundle(file)
+		if err != nil {
+			fail("Error opening '%s': %v\n", file, err)
+		}
+		defer f.Close()
+		bundles = append(bundles, b)
+	}
+
+	dst, closer := createBundle(outputFile, *reproducible)
+	dropped, err := mela.MergeBundles(dst, policy, bundles...)
+	finishBundle(outputFile, dst, closer, err)
+
+	fmt.Printf("Merged %d bundles into '%s', leaving out %d duplicates\n", len(bundles), outputFile, dropped)
+}
+
+func split(execName string, args []string) {
+	fs := flagSet(execName, "split", "<.melarecipes> <output directory>")
+	by := fs.String("by", "category", "how to split the recipes: 'category', 'source' or 'book'")
+	reproducible := reproducibleFlag(fs)
+	_ = fs.Parse(args)
+
+	keys := map[string]mela.SplitKey{"category": mela.SplitByCategory, "source": mela.SplitBySource, "book": mela.SplitByBook}
+	key, ok := keys[*by]
+	if !ok {
+		fail("Unknown split '%s', use 'category', 'source' or 'book'\n", *by)
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	inputFile, outputDir := fs.Arg(0), fs.Arg(1)
+	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
+		fail("Output directory '%s' does not exist\n", outputDir)
+	}
+
+	b, f, err := mela.OpenBundle(inputFile)
+	if err != nil {
+		fail("Error opening '%s': %v\n", inputFile, err)
+	}
+	defer f.Close()
+
+	err = mela.SplitBundle(b, key, func(group string) (*mela.Recipes, error) {
+		if group == "" {
+			group = "no " + *by
+		}
+		fmt.Printf("Writing '%s'\n", group)
+		rs, err := mela.NewRecipesBundle(outputDir, group)
+		if err == nil && *reproducible {
+			err = rs.SetReproducible()
+		}
+		return rs, err
+	})
+	if err != nil {
+		fail("Error splitting '%s': %v\n", inputFile, err)
+	}
+}
+
+func filter(execName string, args []string) {
+	fs := flagSet(execName, "filter", "<.melarecipes> <output .melarecipes>")
+	category := fs.String("category", "", "keep recipes in this category")
+	source := fs.String("source", "", "keep recipes from this source (eg. a website's domain)")
+	isbn := fs.String("isbn", "", "keep recipes from the book with this ISBN")
+	text := fs.String("title", "", "keep recipes whose titles contain this text")
+	favorite := fs.Bool("favorite", false, "keep favorite recipes")
+	wantToCook := fs.Bool("want-to-cook", false, "keep recipes marked as want to cook")
+	invert := fs.Bool("invert", false, "keep the recipes that don't match instead")
+	reproducible := reproducibleFlag(fs)
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	inputFile, outputFile := fs.Arg(0), fs.Arg(1)
+
+	var bookISBN string
+	if *isbn != "" {
+		var r mela.Recipe
+		if err := r.SetBook(*isbn, nil, 0); err != nil {
+			fail("Invalid ISBN '%s': %v\n", *isbn, err)
+		}
+		bookISBN = r.Book().ISBN13
+	}
+
+	keep := func(r *mela.Recipe) bool {
+		matches := (*category == "" || containsFold(r.Categories, *category)) &&
+			(*source == "" || containsFold(mela.SplitBySource(r), *source)) &&
+			(bookISBN == "" || mela.SplitByBook(r)[0] == bookISBN) &&
+			(*text == "" || strings.Contains(strings.ToLower(r.Title), strings.ToLower(*text))) &&
+			(!*favorite || r.Favorite) &&
+			(!*wantToCook || r.WantToCook)
+		return matches != *invert
+	}
+
+	b, f, err := mela.OpenBundle(inputFile)
+	if err != nil {
+		fail("Error opening '%s': %v\n", inputFile, err)
+	}
+	defer f.Close()
+
+	dst, closer := createBundle(outputFile, *reproducible)
+	kept, err := mela.FilterBundle(b, dst, keep)
+	finishBundle(outputFile, dst, closer, err)
+
+	fmt.Printf("Saved %d recipes to '%s'\n", kept, outputFile)
+}
+
+func repack(execName string, args []string) {
+	fs := flagSet(execName, "repack", "<.melarecipes> [...<.melarecipes>]")
+	optimize := fs.Bool("optimize-images", false, "shrink and recompress each recipe's images")
+	standardize := fs.Bool("standardize", false, "standardize each recipe, as mela-standardize does (which optimizes images too)")
+	reproducible := reproducibleFlag(fs)
+	_ = fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	transform := func(r *mela.Recipe) error {
+		if *standardize {
+			if err := r.Standardize(false); err != nil {
+				return err
+			}
+		} else if *optimize {
+			for i, img := range r.Images {
+				optimized, err := img.Optimize()
+				if err != nil {
+					return err
+				}
+				r.Images[i] = optimized
+			}
+		}
+		return nil
+	}
+
+	for _, file := range fs.Args() {
+		before, err := os.Stat(file)
+		if err != nil {
+			fail("Error opening '%s': %v\n", file, err)
+		}
+		if err := mela.RepackBundle(file, *reproducible, transform); err != nil {
+			fail("Error repacking '%s': %v\n", file, err)
+		}
+		after, err := os.Stat(file)
+		if err != nil {
+			fail("Error opening '%s': %v\n", file, err)
+		}
+		fmt.Printf("Repacked '%s' (%d → %d bytes)\n", file, before.Size(), after.Size())
+	}
+}
+
+func reproducibleFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("reproducible", false, "write byte-identical bundles whenever the recipes are the same")
+}
+
+// createBundle creates the output .melarecipes file, refusing to replace an existing one.
+func createBundle(filename string, reproducible bool) (*mela.Recipes, io.Closer) {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		fail("Error creating '%s': %v\n", filename, err)
+	}
+	rs := mela.NewRecipesWriter(f)
+	if reproducible {
+		if err := rs.SetReproducible(); err != nil {
+			fail("Error creating '%s': %v\n", filename, err)
+		}
+	}
+	return rs, f
+}
+
+// finishBundle closes the output .melarecipes file, removing it if it wasn't written successfully.
+func finishBundle(filename string, dst *mela.Recipes, f io.Closer, err error) {
+	if err == nil {
+		err = dst.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filename)
+		fail("Error writing '%s': %v\n", filename, err)
+	}
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func fail(format string, a ...any) {
+	fmt.Fprintf(os.Stderr, format, a...)
+	os.Exit(1)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+var (
+	version = "0.0.0"
+	commit  = "dev"
+	date    = time.Now().Format(time.DateOnly)
+)
+
+func main() {
+	var sizeName string
+	flag.StringVar(&sizeName, "size", mela.CardA4.Name, "the size of card to print on: 'A4', 'Letter', '4x6' or '5x7'")
+	flag.Usage = func() {
+		execName := filepath.Base(os.Args[0])
+		fmt.Printf(
+			"Mela Cards v%s-%s (%s)\n\nUsage: %s [options] <.melarecipe(s)> [...<.melarecipe(s)>] <output .pdf or directory>\n\n"+
+				"A directory gets a PDF for each recipe, a .pdf file gets all the recipes.\n\nOptions:\n",
+			version, commit, date, execName)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	size, ok := mela.LookupCardSize(sizeName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown card size '%s', use 'A4', 'Letter', '4x6' or '5x7'\n", sizeName)
+		os.Exit(1)
+	}
+
+	inputFiles := flag.Args()[:flag.NArg()-1]
+	output := flag.Arg(flag.NArg() - 1)
+
+	var recipes []*mela.Recipe
+	for _, file := range inputFiles {
+		rs, err := mela.Open(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening '%s': %v\n", file, err)
+			os.Exit(1)
+		}
+		recipes = append(recipes, rs...)
+	}
+
+	if info, err := os.Stat(output); err == nil && info.IsDir() {
+		used := make(map[string]bool)
+		for _, r := range recipes {
+			filename := cardsFilename(output, r, used)
+			if err := mela.SaveCards(filename, []*mela.Recipe{r}, size); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing '%s': %v\n", filename, err)
+				os.Exit(1)
+			}
+		}
+		fmt.Printf("Saved %d recipes to '%s'\n", len(recipes), output)
+		return
+	}
+
+	if !strings.EqualFold(filepath.Ext(output), ".pdf") {
+		fmt.Fprintf(os.Stderr, "'%s' must be an existing directory or a .pdf file\n", output)
+		os.Exit(1)
+	}
+	if err := mela.SaveCards(output, recipes, size); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing '%s': %v\n", output, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Saved %d recipes to '%s'\n", len(recipes), output)
+}
+
+// cardsFilename picks a PDF filename in dir for the recipe that hasn't been used already.
+func cardsFilename(dir string, r *mela.Recipe, used map[string]bool) string {
+	base := filepath.Base(r.Filename)
+	if base == "" || base == "." {
+		base = "recipe"
+	}
+
+	name := base + ".pdf"
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s-%d.pdf", base, i)
+	}
+	used[name] = true
+	return filepath.Join(dir, name)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+var (
+	version = "0.0.0"
+	commit  = "dev"
+	date    = time.Now().Format(time.DateOnly)
+)
+
+func main() {
+	var sheet string
+	flag.StringVar(&sheet, "apply", "", "a (.csv or .tsv) spreadsheet of metadata to apply to the recipes")
+	flag.Usage = func() {
+		execName := filepath.Base(os.Args[0])
+		fmt.Printf(
+			"Mela CSV v%s-%s (%s)\n\nUsage: %s <.melarecipe(s)> [...<.melarecipe(s)>] <output .csv or .tsv>\n"+
+				"       %s -apply <.csv or .tsv> <.melarecipe(s)> [...<.melarecipe(s)>] <output directory>\n\nOptions:\n",
+			version, commit, date, execName, execName)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	inputFiles := flag.Args()[:flag.NArg()-1]
+	output := flag.Arg(flag.NArg() - 1)
+
+	var recipes []*mela.Recipe
+	for _, file := range inputFiles {
+		rs, err := mela.Open(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening '%s': %v\n", file, err)
+			os.Exit(1)
+		}
+		recipes = append(recipes, rs...)
+	}
+
+	if sheet == "" {
+		exportMetadata(recipes, output)
+	} else {
+		applyMetadata(recipes, sheet, output)
+	}
+}
+
+func exportMetadata(recipes []*mela.Recipe, output string) {
+	comma := ','
+	if strings.EqualFold(filepath.Ext(output), ".tsv") {
+		comma = '\t'
+	}
+
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating '%s': %v\n", output, err)
+		os.Exit(1)
+	}
+	if err := mela.WriteMetadataCSV(f, recipes, comma); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing '%s': %v\n", output, err)
+		os.Exit(1)
+	}
+	if err := f.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing '%s': %v\n", output, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Saved metadata for %d recipes to '%s'\n", len(recipes), output)
+}
+
+func applyMetadata(recipes []*mela.Recipe, sheet, outputDir string) {
+	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Output directory '%s' does not exist\n", outputDir)
+		os.Exit(1)
+	}
+
+	f, err := os.Open(sheet)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening '%s': %v\n", sheet, err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	unmatched, err := mela.ApplyMetadataCSV(f, recipes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading '%s': %v\n", sheet, err)
+		os.Exit(1)
+	}
+	for _, u := range unmatched {
+		fmt.Printf("⚠ Row %d skipped (%s): %s\n", u.Line, u.Reason, u.Text)
+	}
+
+	for _, r := range recipes {
+		dest, err := r.Save(outputDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving '%s': %v\n", r.Title, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Saved '%s' to '%s'\n", r.Title, dest)
+	}
+}
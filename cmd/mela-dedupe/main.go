@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+var (
+	version = "0.0.0"
+	commit  = "dev"
+	date    = time.Now().Format(time.DateOnly)
+)
+
+func main() {
+	var opts mela.DedupeOptions
+	var keep, output string
+	flag.Float64Var(&opts.MinScore, "min-score", 0.75, "how certain (0 to 1) a match must be to count as a duplicate")
+	flag.StringVar(&keep, "keep", strings.Join(mela.DefaultKeepRules, ","),
+		"the rules for choosing the copy to keep, in order: 'favorite', 'book', 'images', 'complete', 'newest' or 'oldest'")
+	flag.StringVar(&output, "out", "", "a .melarecipes file to save the recipes to, with only the best copy of each duplicate")
+	flag.Usage = func() {
+		execName := filepath.Base(os.Args[0])
+		fmt.Printf(
+			"Mela Dedupe v%s-%s (%s)\n\nUsage: %s [options] <.melarecipe(s)> [...<.melarecipe(s)>]\n\nOptions:\n",
+			version, commit, date, execName)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var rules []mela.KeepRule
+	for _, name := range strings.Split(keep, ",") {
+		rule, ok := mela.KeepRules[strings.TrimSpace(name)]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown rule '%s', use 'favorite', 'book', 'images', 'complete', 'newest' or 'oldest'\n", name)
+			os.Exit(1)
+		}
+		rules = append(rules, rule)
+	}
+
+	var recipes []*mela.Recipe
+	var sources []string
+	for _, file := range flag.Args() {
+		rs, err := mela.Open(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening '%s': %v\n", file, err)
+			os.Exit(1)
+		}
+		for range rs {
+			sources = append(sources, file)
+		}
+		recipes = append(recipes, rs...)
+	}
+
+	clusters := mela.FindDuplicates(recipes, opts)
+	dropped := make(map[int]bool)
+	for _, c := range clusters {
+		copies := make([]*mela.Recipe, len(c.Recipes))
+		for i, n := range c.Recipes {
+			copies[i] = recipes[n]
+		}
+		best := c.Recipes[mela.BestCopy(copies, rules...)]
+
+		// Only direct duplicates of the copy kept are dropped, not those in the cluster through another recipe
+		fmt.Printf("Duplicates (score %.2f, matching %s):\n", c.Score, clusterReasons(c))
+		for _, n := range c.DuplicatesOf(best) {
+			dropped[n] = true
+		}
+		for _, n := range c.Recipes {
+			mark, note := " ", ""
+			switch {
+			case n == best:
+				mark = "★"
+			case !dropped[n]:
+				mark, note = "+", " (kept, not a direct duplicate of ★)"
+			}
+			fmt.Printf("  %s %s [%s]%s\n", mark, recipes[n].Title, sources[n], note)
+		}
+	}
+	fmt.Printf("Found %d sets of duplicates among %d recipes\n", len(clusters), len(recipes))
+
+	if output == "" {
+		return
+	}
+
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating '%s': %v\n", output, err)
+		os.Exit(1)
+	}
+	bundle := mela.NewRecipesWriter(f)
+	for n, r := range recipes {
+		if dropped[n] {
+			continue
+		}
+		if err := bundle.Add(r); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing '%s': %v\n", output, err)
+			os.Exit(1)
+		}
+	}
+	if err := bundle.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing '%s': %v\n", output, err)
+		os.Exit(1)
+	}
+	if err := f.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing '%s': %v\n", output, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Saved %d recipes to '%s'\n", len(recipes)-len(dropped), output)
+}
+
+// clusterReasons lists the reasons any of the cluster's recipes were found to be duplicates.
+func clusterReasons(c mela.DuplicateCluster) string {
+	seen := make(map[mela.DuplicateReason]bool)
+	var reasons []string
+	for _, m := range c.Matches {
+		for _, reason := range m.Reasons {
+			if !seen[reason] {
+				seen[reason] = true
+				reasons = append(reasons, string(reason))
+			}
+		}
+	}
+	if len(reasons) == 0 {
+		return "overall similarity"
+	}
+	return strings.Join(reasons, ", ")
+}
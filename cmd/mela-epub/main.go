@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+var (
+	version = "0.0.0"
+	commit  = "dev"
+	date    = time.Now().Format(time.DateOnly)
+)
+
+func main() {
+	var opts mela.EPUBOptions
+	var groupBy, coverFile string
+	flag.StringVar(&opts.Title, "title", "Recipes", "the title of the book")
+	flag.StringVar(&opts.Author, "author", "", "the author of the book")
+	flag.StringVar(&opts.Language, "lang", "en", "the language of the book")
+	flag.StringVar(&groupBy, "group", string(mela.EPUBByCategory), "how to split recipes into chapters: 'category' or 'source'")
+	flag.StringVar(&coverFile, "cover", "", "an image to use as the cover (defaults to the first recipe image)")
+	flag.Usage = func() {
+		execName := filepath.Base(os.Args[0])
+		fmt.Printf(
+			"Mela EPUB v%s-%s (%s)\n\nUsage: %s [options] <.melarecipe(s)> [...<.melarecipe(s)>] <output .epub>\n\nOptions:\n",
+			version, commit, date, execName)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	opts.GroupBy = mela.EPUBGrouping(groupBy)
+	if opts.GroupBy != mela.EPUBByCategory && opts.GroupBy != mela.EPUBBySource {
+		fmt.Fprintf(os.Stderr, "Unknown grouping '%s', use 'category' or 'source'\n", groupBy)
+		os.Exit(1)
+	}
+
+	if coverFile != "" {
+		cover, err := os.ReadFile(coverFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening cover image '%s': %v\n", coverFile, err)
+			os.Exit(1)
+		}
+		opts.Cover = cover
+	}
+
+	inputFiles := flag.Args()[:flag.NArg()-1]
+	outputFile := flag.Arg(flag.NArg() - 1)
+
+	var recipes []*mela.Recipe
+	for _, file := range inputFiles {
+		rs, err := mela.Open(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening '%s': %v\n", file, err)
+			os.Exit(1)
+		}
+		recipes = append(recipes, rs...)
+	}
+
+	if err := mela.SaveEPUB(outputFile, recipes, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing '%s': %v\n", outputFile, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Saved %d recipes to '%s'\n", len(recipes), outputFile)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+var (
+	version = "0.0.0"
+	commit  = "dev"
+	date    = time.Now().Format(time.DateOnly)
+)
+
+type rule struct {
+	name  string
+	check func(*mela.Recipe) []string
+}
+
+var rules = []rule{
+	{"ingredients", checkIngredients},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		execName := filepath.Base(os.Args[0])
+		fmt.Printf(
+			"Mela Lint v%s-%s (%s)\n\nUsage: %s <.melarecipe(s)> [...<.melarecipe(s)>]\n",
+			version, commit, date, execName)
+		os.Exit(1)
+	}
+
+	problems := 0
+	for _, file := range os.Args[1:] {
+		recipes, err := mela.Open(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening '%s': %v\n", file, err)
+			os.Exit(1)
+		}
+
+		for _, r := range recipes {
+			for _, rl := range rules {
+				for _, p := range rl.check(r) {
+					fmt.Printf("%s: '%s' [%s] %s\n", file, r.Title, rl.name, p)
+					problems++
+				}
+			}
+		}
+	}
+
+	if problems > 0 {
+		os.Exit(2)
+	}
+}
+
+func checkIngredients(r *mela.Recipe) []string {
+	report := r.CheckIngredients()
+
+	var problems []string
+	for _, ing := range report.Unused {
+		problems = append(problems, fmt.Sprintf("'%s' is listed but never used in the instructions", ing.Name))
+	}
+	if len(report.Unlisted) > 0 {
+		problems = append(problems, fmt.Sprintf("instructions mention %s, which aren't in the ingredients list", strings.Join(report.Unlisted, ", ")))
+	}
+	return problems
+}
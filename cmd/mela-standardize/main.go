@@ -48,6 +48,9 @@ func main() {
 			for _, s := range r.ListStandardizations() {
 				fmt.Printf("→ %s\n", s)
 			}
+			for _, w := range r.ListWarnings() {
+				fmt.Printf("⚠ %s\n", w)
+			}
 
 			dest, err := r.Save(outputDir)
 			if err != nil {
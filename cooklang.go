@@ -0,0 +1,431 @@
+package mela
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var cooklangIngredient = regexp.MustCompile(`@([^@#~{}\n]+?)\{([^}]*)\}(?:\(([^)]*)\))?|@([\p{L}\p{N}_-]+)(?:\(([^)]*)\))?`)
+var cooklangCookware = regexp.MustCompile(`#([^@#~{}\n]+?)\{([^}]*)\}|#([\p{L}\p{N}_-]+)`)
+var cooklangTimer = regexp.MustCompile(`~([^@#~{}\n]*?)\{([^}]*)\}`)
+var cooklangLineComment = regexp.MustCompile(`\s*--.*$`)
+var cooklangBlockComment = regexp.MustCompile(`(?s)\[-.*?-\]`)
+var cooklangSection = regexp.MustCompile(`^=+\s*(.*?)\s*=*$`)
+var cooklangMetadata = regexp.MustCompile(`^>>\s*([^:]+?)\s*:\s*(.*)$`)
+
+// Characters escaped with a backslash are set aside (as private use characters) while a file is parsed, so they aren't
+// taken as markers, comments, notes or sections.
+var cooklangProtectEscapes = strings.NewReplacer(`\\`, "\uE000", `\@`, "\uE001", `\#`, "\uE002", `\~`, "\uE003", `\-`, "\uE004",
+	`\>`, "\uE005", `\=`, "\uE006", `\[`, "\uE007")
+var cooklangRestoreEscapes = strings.NewReplacer("\uE000", `\`, "\uE001", "@", "\uE002", "#", "\uE003", "~", "\uE004", "-",
+	"\uE005", ">", "\uE006", "=", "\uE007", "[")
+
+// cooklangMeta is the front matter written by WriteCooklang, in the order it's written.
+type cooklangMeta struct {
+	Title       string   `yaml:"title,omitempty"`
+	ID          string   `yaml:"id,omitempty"`
+	Source      string   `yaml:"source,omitempty"`
+	Servings    string   `yaml:"servings,omitempty"`
+	PrepTime    string   `yaml:"prep time,omitempty"`
+	CookTime    string   `yaml:"cook time,omitempty"`
+	Time        string   `yaml:"time,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+}
+
+// ParseCooklang parses a Cooklang (.cook) recipe. Ingredients are collected from the @ingredient markers in each step,
+// sections (== Section ==) become Mela headings, and metadata (as YAML front matter or >> lines) is mapped onto the
+// matching fields. Characters escaped with a backslash (eg. "\@") are kept as text. Cooklang recipes often take their
+// title from their filename, so Title may need setting.
+func ParseCooklang(r io.Reader) (*Recipe, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	recipe := &Recipe{Categories: make([]string, 0), Images: make([]B64Image, 0)}
+	body, meta, err := cooklangFrontMatter(string(data))
+	if err != nil {
+		return nil, err
+	}
+	body = cooklangBlockComment.ReplaceAllString(cooklangProtectEscapes.Replace(body), "")
+
+	var ingredients, instructions []Section
+	var notes, paragraph []string
+	heading := ""
+
+	endStep := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		step, ings := cooklangStep(strings.Join(paragraph, " "))
+		paragraph = nil
+
+		if len(instructions) == 0 || instructions[len(instructions)-1].Heading != heading {
+			instructions = append(instructions, Section{Heading: heading})
+		}
+		instructions[len(instructions)-1].Lines = append(instructions[len(instructions)-1].Lines, step)
+
+		if len(ings) == 0 {
+			return
+		}
+		if len(ingredients) == 0 || ingredients[len(ingredients)-1].Heading != heading {
+			ingredients = append(ingredients, Section{Heading: heading})
+		}
+		ingredients[len(ingredients)-1].Lines = append(ingredients[len(ingredients)-1].Lines, ings...)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(cooklangLineComment.ReplaceAllString(scanner.Text(), ""))
+
+		if m := cooklangMetadata.FindStringSubmatch(line); m != nil {
+			meta[strings.ToLower(m[1])] = cooklangRestoreEscapes.Replace(m[2])
+			continue
+		}
+		if note, ok := strings.CutPrefix(line, ">"); ok {
+			notes = append(notes, strings.TrimSpace(note))
+			continue
+		}
+		if m := cooklangSection.FindStringSubmatch(line); m != nil {
+			endStep()
+			heading = m[1]
+			continue
+		}
+		if line == "" {
+			endStep()
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	endStep()
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	recipe.Ingredients = SectionedSequence(cooklangRestoreEscapes.Replace(string(NewSectionedSequence(ingredients))))
+	recipe.Instructions = SectionedSequence(cooklangRestoreEscapes.Replace(string(NewSectionedSequence(instructions))))
+	recipe.Notes = cooklangRestoreEscapes.Replace(strings.Join(notes, "\n"))
+	applyCooklangMeta(recipe, meta)
+
+	return recipe, nil
+}
+
+// cooklangFrontMatter separates any YAML front matter from the body of a Cooklang file.
+func cooklangFrontMatter(data string) (string, map[string]any, error) {
+	meta := make(map[string]any)
+	rest, ok := strings.CutPrefix(data, "---\n")
+	if !ok {
+		return data, meta, nil
+	}
+
+	front, body, ok := strings.Cut(rest, "\n---")
+	if !ok {
+		return data, meta, nil
+	}
+
+	var raw map[string]any
+	if err := yaml.Unmarshal([]byte(front), &raw); err != nil {
+		return "", nil, fmt.Errorf("invalid front matter: %w", err)
+	}
+	for k, v := range raw {
+		meta[strings.ToLower(k)] = v
+	}
+
+	return strings.TrimPrefix(body, "\n"), meta, nil
+}
+
+func applyCooklangMeta(r *Recipe, meta map[string]any) {
+	first := func(keys ...string) string {
+		for _, k := range keys {
+			if v := metaString(meta[k]); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	r.Title = first("title")
+	r.ID = first("id")
+	r.Text = first("description", "introduction")
+	r.Link = first("source", "source.url", "url", "source.name", "author")
+	// As with recipes from web pages, a recipe without an ID is identified by its web link
+	if r.ID == "" && (strings.HasPrefix(r.Link, "http://") || strings.HasPrefix(r.Link, "https://")) {
+		r.ID = r.Link
+	}
+	r.Yield = PeopleCount(first("servings", "serves", "yield"))
+	r.PrepTime = MaybeDuration(first("prep time", "time.prep", "prep_time"))
+	r.CookTime = MaybeDuration(first("cook time", "time.cook", "cook_time"))
+	r.TotalTime = MaybeDuration(first("time", "total time", "duration"))
+
+	for _, key := range []string{"tags", "categories", "course"} {
+		switch v := meta[key].(type) {
+		case []any:
+			for _, item := range v {
+				if s := metaString(item); s != "" {
+					r.Categories = append(r.Categories, s)
+				}
+			}
+		case string:
+			for _, s := range strings.Split(v, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					r.Categories = append(r.Categories, s)
+				}
+			}
+		}
+	}
+}
+
+func metaString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(val)
+	case map[string]any:
+		// eg. "source: {name: ..., url: ...}"
+		for _, k := range []string{"url", "name"} {
+			if s := metaString(val[k]); s != "" {
+				return s
+			}
+		}
+		return ""
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// cooklangStep replaces the markers in a step with plain text, returning the step and the ingredient lines it uses.
+func cooklangStep(step string) (string, []string) {
+	var ings []string
+	step = cooklangIngredient.ReplaceAllStringFunc(step, func(marker string) string {
+		m := cooklangIngredient.FindStringSubmatch(marker)
+		name, amount, note := m[1], m[2], m[3]
+		if name == "" {
+			name, note = m[4], m[5]
+		}
+		name = strings.TrimSpace(name)
+
+		line := name
+		if qty, unit, _ := strings.Cut(amount, "%"); strings.TrimSpace(qty) == "" && strings.TrimSpace(unit) != "" {
+			// eg. "@salt{%pinch}"
+			line = strings.TrimSpace(unit) + " of " + name
+		} else if qty := cooklangAmount(amount); qty != "" {
+			line = qty + " " + name
+		}
+		if note != "" {
+			line += ", " + note
+		}
+		ings = append(ings, line)
+
+		return name
+	})
+
+	step = cooklangCookware.ReplaceAllStringFunc(step, func(marker string) string {
+		m := cooklangCookware.FindStringSubmatch(marker)
+		if m[1] != "" {
+			return strings.TrimSpace(m[1])
+		}
+		return m[3]
+	})
+
+	step = cooklangTimer.ReplaceAllStringFunc(step, func(marker string) string {
+		m := cooklangTimer.FindStringSubmatch(marker)
+		if amount := cooklangAmount(m[2]); amount != "" {
+			return amount
+		}
+		return strings.TrimSpace(m[1])
+	})
+
+	return step, ings
+}
+
+// cooklangAmount turns a "quantity%unit" amount into "quantity unit".
+func cooklangAmount(amount string) string {
+	qty, unit, _ := strings.Cut(amount, "%")
+	qty, unit = strings.TrimSpace(qty), strings.TrimSpace(unit)
+	if unit == "" {
+		return qty
+	}
+	return qty + " " + unit
+}
+
+// WriteCooklang writes the recipe as Cooklang. Each ingredient is marked up where a step first mentions it, and any
+// ingredients the steps never mention are listed in a final step so that none are lost. Characters in the text that
+// Cooklang would read as markers or comments are escaped with a backslash, as ParseCooklang expects.
+func (r *Recipe) WriteCooklang(w io.Writer) error {
+	meta := cooklangMeta{
+		Title:       r.Title,
+		ID:          r.ID,
+		Source:      r.Link,
+		Servings:    string(r.Yield),
+		PrepTime:    string(r.PrepTime),
+		CookTime:    string(r.CookTime),
+		Time:        string(r.TotalTime),
+		Tags:        r.Categories,
+		Description: r.Text,
+	}
+
+	front, err := yaml.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("unable to write front matter: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(front)
+	buf.WriteString("---\n")
+
+	sections := r.Instructions.Sections()
+	markers := make([][]cooklangMarker, len(sections))
+	var unmentioned []string
+
+	for _, ing := range r.IngredientList() {
+		if ing.Name == "" {
+			continue
+		}
+		if !placeCooklangMarker(sections, markers, ing) {
+			unmentioned = append(unmentioned, cooklangIngredientMarker(ing))
+		}
+	}
+
+	for i, sec := range sections {
+		if sec.Heading != "" {
+			fmt.Fprintf(&buf, "\n== %s ==\n", escapeCooklang(sec.Heading))
+		}
+		for j, line := range sec.Lines {
+			fmt.Fprintf(&buf, "\n%s\n", applyCooklangMarkers(line, markers[i], j))
+		}
+	}
+
+	if len(unmentioned) > 0 {
+		fmt.Fprintf(&buf, "\nYou will also need %s.\n", strings.Join(unmentioned, ", "))
+	}
+
+	if r.Notes != "" {
+		buf.WriteString("\n")
+		for _, line := range strings.Split(r.Notes, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Fprintf(&buf, "> %s\n", escapeCooklang(line))
+			}
+		}
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// cooklangMarker is an ingredient marker to be placed over a word in a step.
+type cooklangMarker struct {
+	line       int
+	start, end int
+	text       string
+}
+
+func placeCooklangMarker(sections []Section, markers [][]cooklangMarker, ing Ingredient) bool {
+	for i, sec := range sections {
+		for j, line := range sec.Lines {
+			offset := 0
+			for offset < len(line) {
+				loc := ing.mentionIn(line[offset:])
+				if loc == nil {
+					break
+				}
+				start, end := offset+loc[0], offset+loc[1]
+				offset = end
+
+				if overlapsCooklangMarker(markers[i], j, start, end) {
+					continue
+				}
+				markers[i] = append(markers[i], cooklangMarker{line: j, start: start, end: end, text: cooklangIngredientMarker(ing)})
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func overlapsCooklangMarker(markers []cooklangMarker, line, start, end int) bool {
+	for _, m := range markers {
+		if m.line == line && start < m.end && m.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+func applyCooklangMarkers(line string, markers []cooklangMarker, lineNum int) string {
+	var onLine []cooklangMarker
+	for _, m := range markers {
+		if m.line == lineNum {
+			onLine = append(onLine, m)
+		}
+	}
+	sort.Slice(onLine, func(i, j int) bool { return onLine[i].start < onLine[j].start })
+
+	// The text between markers is escaped, so it isn't read back as markers or comments
+	var out strings.Builder
+	end := 0
+	for _, m := range onLine {
+		out.WriteString(escapeCooklang(line[end:m.start]))
+		out.WriteString(m.text)
+		end = m.end
+	}
+	out.WriteString(escapeCooklang(line[end:]))
+
+	escaped := out.String()
+	if len(onLine) == 0 || onLine[0].start > 0 {
+		escaped = escapeCooklangLineStart(escaped)
+	}
+	return escaped
+}
+
+// escapeCooklang backslash-escapes the characters that would start a marker (@, #, ~) or comment (--, [-) in Cooklang.
+func escapeCooklang(text string) string {
+	var out strings.Builder
+	for i, c := range text {
+		switch {
+		case c == '\\' || c == '@' || c == '#' || c == '~':
+			out.WriteRune('\\')
+		case c == '-' && ((i > 0 && (text[i-1] == '-' || text[i-1] == '[')) || (i+1 < len(text) && text[i+1] == '-')):
+			out.WriteRune('\\')
+		}
+		out.WriteRune(c)
+	}
+	return out.String()
+}
+
+// escapeCooklangLineStart escapes a > or = at the start of a line, which would make it a note or section.
+func escapeCooklangLineStart(line string) string {
+	if strings.HasPrefix(line, ">") || strings.HasPrefix(line, "=") {
+		return `\` + line
+	}
+	return line
+}
+
+func cooklangIngredientMarker(ing Ingredient) string {
+	amount := ""
+	if ing.Quantity > 0 {
+		amount = formatQuantity(ing.Quantity)
+		if ing.MaxQuantity > ing.Quantity {
+			amount += "-" + formatQuantity(ing.MaxQuantity)
+		}
+	}
+	// A unit without a quantity is kept too, eg. "Pinch of salt" as "@salt{%pinch}"
+	if ing.Unit != "" {
+		amount += "%" + ing.Unit
+	}
+
+	marker := "@" + ing.Name + "{" + amount + "}"
+	if ing.Note != "" {
+		marker += "(" + ing.Note + ")"
+	}
+	return marker
+}
@@ -0,0 +1,161 @@
+package mela_test
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func TestParseCooklang(t *testing.T) {
+	f, err := os.Open("fixtures/cooklang/pancakes.cook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := mela.ParseCooklang(f)
+	if err != nil {
+		t.Fatalf("Unable to parse Cooklang: %v", err)
+	}
+
+	want := &mela.Recipe{
+		ID:          "https://cooklang.org/pancakes",
+		Title:       "Easy Pancakes",
+		Link:        "https://cooklang.org/pancakes",
+		Categories:  []string{"breakfast", "sweet"},
+		Images:      []mela.B64Image{},
+		Yield:       "4",
+		PrepTime:    "10 minutes",
+		CookTime:    "20 minutes",
+		Notes:       "Serve with lemon and sugar.",
+		Ingredients: "3 eggs\n125 g plain flour\n250 ml milk\n1 pinch sea salt\n# Cooking\nbutter, for frying",
+		Instructions: "Crack the eggs into a blender, then add the plain flour, milk and sea salt, and blitz until smooth.\n" +
+			"Pour into a bowl and leave to stand for 15 minutes.\n" +
+			"# Cooking\n" +
+			"Melt the butter in a large non-stick frying pan on a medium heat. Tilt the pan so the butter coats the surface.\n" +
+			"Pour in 1 ladle of batter and cook for 1-2 minutes on each side.",
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Incorrect recipe:\nwant = %#v\ngot  = %#v", want, got)
+	}
+}
+
+func TestRecipe_WriteCooklang(t *testing.T) {
+	r := &mela.Recipe{
+		ID:           "abc",
+		Title:        "Shallot pasta",
+		Link:         "https://example.com/shallot-pasta",
+		Categories:   []string{"pasta"},
+		Yield:        "2",
+		TotalTime:    "30 mins",
+		Ingredients:  "4 banana shallots, sliced\n200g spaghetti\n1-2 tbsp olive oil\nSalt",
+		Instructions: "Fry the shallots in the oil.\n# Pasta\nBoil the spaghetti.\nToss the spaghetti with the shallots.",
+		Notes:        "Add chilli if you like.",
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteCooklang(&buf); err != nil {
+		t.Fatalf("Unable to write Cooklang: %v", err)
+	}
+
+	want := `---
+title: Shallot pasta
+id: abc
+source: https://example.com/shallot-pasta
+servings: "2"
+time: 30 mins
+tags:
+    - pasta
+---
+
+Fry the @banana shallots{4}(sliced) in the @olive oil{1-2%tbsp}.
+
+== Pasta ==
+
+Boil the @spaghetti{200%g}.
+
+Toss the spaghetti with the shallots.
+
+You will also need @Salt{}.
+
+> Add chilli if you like.
+`
+	if buf.String() != want {
+		t.Errorf("Incorrect Cooklang:\nwant = %s\ngot  = %s", want, buf.String())
+	}
+
+	roundTrip, err := mela.ParseCooklang(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("Unable to parse written Cooklang: %v", err)
+	}
+	if roundTrip.ID != r.ID || roundTrip.Title != r.Title || roundTrip.Link != r.Link || roundTrip.Yield != r.Yield || roundTrip.TotalTime != r.TotalTime ||
+		roundTrip.Notes != r.Notes || !reflect.DeepEqual(roundTrip.Categories, r.Categories) {
+		t.Errorf("Metadata changed in round trip: want = %#v, got = %#v", r, roundTrip)
+	}
+	if got := len(roundTrip.IngredientList()); got != 4 {
+		t.Errorf("Incorrect number of ingredients after round trip: want = 4, got = %d", got)
+	}
+}
+
+func TestRecipe_WriteCooklang_WebRecipeID(t *testing.T) {
+	r := &mela.Recipe{
+		ID:           "https://example.com/toast",
+		Title:        "Toast",
+		Link:         "https://example.com/toast",
+		Ingredients:  "1 slice bread",
+		Instructions: "Toast the bread.",
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteCooklang(&buf); err != nil {
+		t.Fatalf("Unable to write Cooklang: %v", err)
+	}
+	roundTrip, err := mela.ParseCooklang(&buf)
+	if err != nil {
+		t.Fatalf("Unable to parse written Cooklang: %v", err)
+	}
+	if roundTrip.ID != r.ID {
+		t.Errorf("ID changed in round trip: want = %s, got = %s", r.ID, roundTrip.ID)
+	}
+}
+
+func TestRecipe_WriteCooklang_Escaping(t *testing.T) {
+	r := &mela.Recipe{
+		Title:        "Special characters",
+		Ingredients:  "Pinch of salt\n2 eggs",
+		Instructions: "Beat the eggs -- gently -- with a fork (a #2 whisk @ home works too).\n> 1 minute, ~ish.\nSeason with the salt.",
+		Notes:        "Halve the eggs for a [-smaller-] batch.",
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteCooklang(&buf); err != nil {
+		t.Fatalf("Unable to write Cooklang: %v", err)
+	}
+	if !strings.Contains(buf.String(), "@salt{%pinch}") {
+		t.Errorf("Expected the salt's unit to be kept, got:\n%s", buf.String())
+	}
+
+	roundTrip, err := mela.ParseCooklang(&buf)
+	if err != nil {
+		t.Fatalf("Unable to parse written Cooklang: %v", err)
+	}
+	if roundTrip.Instructions != r.Instructions {
+		t.Errorf("Instructions changed in round trip:\nwant = %q\ngot  = %q", r.Instructions, roundTrip.Instructions)
+	}
+	if roundTrip.Notes != r.Notes {
+		t.Errorf("Notes changed in round trip: want = %q, got = %q", r.Notes, roundTrip.Notes)
+	}
+
+	want := []mela.Ingredient{
+		{Line: "2 eggs", Quantity: 2, MaxQuantity: 2, Name: "eggs"},
+		{Line: "pinch of salt", Unit: "pinch", Name: "salt"},
+	}
+	if got := roundTrip.IngredientList(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Incorrect ingredients after round trip: want = %#v, got = %#v", want, got)
+	}
+}
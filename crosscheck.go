@@ -0,0 +1,70 @@
+package mela
+
+import (
+	"sort"
+	"strings"
+)
+
+// IngredientReport lists the mismatches between a recipe's ingredients and its instructions.
+type IngredientReport struct {
+	// Unused holds listed ingredients that the instructions never mention
+	Unused []Ingredient
+	// Unlisted holds common ingredients the instructions mention that aren't in the ingredients list
+	Unlisted []string
+}
+
+// commonIngredients are the words recognised as ingredients when looking for ones the instructions mention but the
+// ingredients list doesn't. Water is deliberately absent, as it's rarely listed.
+var commonIngredients = []string{
+	"almond", "anchovy", "apple", "apricot", "aubergine", "avocado", "bacon", "banana", "basil", "bay", "bean",
+	"beef", "beetroot", "breadcrumb", "broccoli", "butter", "buttermilk", "cabbage", "caper", "cardamom", "carrot",
+	"cauliflower", "celery", "cheddar", "cheese", "chicken", "chickpea", "chilli", "chili", "chive", "chocolate",
+	"chorizo", "cinnamon", "clove", "cocoa", "coconut", "cod", "coriander", "courgette", "cream", "cucumber", "cumin",
+	"dill", "egg", "fennel", "feta", "flour", "garlic", "ginger", "halloumi", "ham", "honey", "kale", "lamb", "leek",
+	"lemon", "lentil", "lettuce", "lime", "mascarpone", "mayonnaise", "milk", "mint", "mozzarella", "mushroom",
+	"mustard", "noodle", "nutmeg", "oat", "oil", "olive", "onion", "orange", "oregano", "paprika", "parmesan",
+	"parsley", "parsnip", "pasta", "pea", "peanut", "pear", "pecan", "pepper", "pork", "potato", "prawn", "raisin",
+	"rice", "ricotta", "rosemary", "saffron", "sage", "salmon", "salt", "sausage", "scallion", "sesame", "shallot",
+	"spinach", "sugar", "sultana", "syrup", "tahini", "tarragon", "thyme", "tofu", "tomato", "tuna", "turmeric",
+	"vanilla", "vinegar", "walnut", "wine", "yeast", "yoghurt", "yogurt",
+}
+
+// CheckIngredients cross-checks the ingredients list against the instructions, finding ingredients that are never
+// used and ingredients that are used but never listed.
+func (r *Recipe) CheckIngredients() IngredientReport {
+	var report IngredientReport
+	ings := r.IngredientList()
+	method := string(r.Instructions)
+
+	listedWords := make(map[string]bool)
+	for _, ing := range ings {
+		if ing.Name == "" {
+			continue
+		}
+		if !ing.mentionedIn(method) {
+			report.Unused = append(report.Unused, ing)
+		}
+		for _, w := range wordSplitter.Split(strings.ToLower(ing.Name+" "+ing.Unit+" "+ing.Note), -1) {
+			listedWords[singular(w)] = true
+		}
+	}
+
+	methodWords := make(map[string]bool)
+	for _, w := range wordSplitter.Split(strings.ToLower(method), -1) {
+		methodWords[singular(w)] = true
+	}
+
+	for _, common := range commonIngredients {
+		if methodWords[common] && !listedWords[common] {
+			report.Unlisted = append(report.Unlisted, common)
+		}
+	}
+	sort.Strings(report.Unlisted)
+
+	return report
+}
+
+// OK is true when the ingredients and instructions match up.
+func (ir IngredientReport) OK() bool {
+	return len(ir.Unused) == 0 && len(ir.Unlisted) == 0
+}
@@ -0,0 +1,48 @@
+package mela_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/jphastings/mela-recipes"
+)
+
+func TestRecipe_CheckIngredients(t *testing.T) {
+	type test struct {
+		name         string
+		ingredients  SectionedSequence
+		instructions SectionedSequence
+		wantUnused   []string
+		wantUnlisted []string
+	}
+
+	tests := []test{
+		{"All matched", "2 shallots\n1 tbsp olive oil\n3 cloves garlic", "Fry the shallots and garlic cloves in the oil.", nil, nil},
+		{"Unlisted shallots", "1 tbsp olive oil", "Fry the shallots in the oil.", nil, []string{"shallot"}},
+		{"Unused ingredient", "1 tbsp olive oil\n100g feta", "Heat the oil.", []string{"feta"}, nil},
+		{"Both", "# Sauce\n400g tomatoes\nBasil leaves", "Simmer the tomatoes with the onions and add the butter.", []string{"Basil leaves"}, []string{"butter", "onion"}},
+		{"Unlisted chillies", "1 onion", "Fry the onion with the chillies.", nil, []string{"chilli"}},
+		{"Listed chillies", "2 red chillies", "Fry the chilli.", nil, nil},
+		{"Water is ignored", "200g rice", "Boil the rice in water.", nil, nil},
+	}
+
+	for _, test := range tests {
+		r := &Recipe{Ingredients: test.ingredients, Instructions: test.instructions}
+		report := r.CheckIngredients()
+
+		var gotUnused []string
+		for _, ing := range report.Unused {
+			gotUnused = append(gotUnused, ing.Name)
+		}
+
+		if !reflect.DeepEqual(test.wantUnused, gotUnused) {
+			t.Errorf("Incorrect unused ingredients for '%s': want = %v, got = %v", test.name, test.wantUnused, gotUnused)
+		}
+		if !reflect.DeepEqual(test.wantUnlisted, report.Unlisted) {
+			t.Errorf("Incorrect unlisted ingredients for '%s': want = %v, got = %v", test.name, test.wantUnlisted, report.Unlisted)
+		}
+		if report.OK() != (test.wantUnused == nil && test.wantUnlisted == nil) {
+			t.Errorf("Incorrect OK status for '%s'", test.name)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package mela
+
+import (
+	"math"
+	"time"
+)
+
+// AppleDate is a timestamp as Mela stores it: seconds since the start of 2001 (UTC).
+type AppleDate float64
+
+var appleEpoch = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func NewAppleDate(t time.Time) AppleDate {
+	return AppleDate(t.Sub(appleEpoch).Seconds())
+}
+
+func (d AppleDate) Time() time.Time {
+	secs, frac := math.Modf(float64(d))
+	return appleEpoch.Add(time.Duration(secs) * time.Second).Add(time.Duration(frac * float64(time.Second)))
+}
@@ -0,0 +1,437 @@
+package mela
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/transform"
+)
+
+// DuplicateReason is something two recipes have in common that suggests they're the same recipe.
+type DuplicateReason string
+
+const (
+	SameID             DuplicateReason = "id"
+	SameLink           DuplicateReason = "link"
+	SameBookRecipe     DuplicateReason = "book"
+	SimilarTitle       DuplicateReason = "title"
+	SimilarIngredients DuplicateReason = "ingredients"
+)
+
+// Scores given to recipes that share an exact identifier.
+const (
+	sameIDScore   = 1.0
+	sameLinkScore = 0.95
+	sameBookScore = 0.95
+)
+
+// DedupeOptions are the thresholds used by FindDuplicates. Zero values use the defaults.
+type DedupeOptions struct {
+	// MinScore is the score (0 to 1) two recipes need to be counted as duplicates. Default: 0.75
+	MinScore float64
+	// TitleSimilarity is how similar (0 to 1) two titles must be to be given as a reason. Default: 0.8
+	TitleSimilarity float64
+	// IngredientSimilarity is how similar (0 to 1) two ingredient lists must be to be given as a reason. Default: 0.6
+	IngredientSimilarity float64
+}
+
+func (o DedupeOptions) withDefaults() DedupeOptions {
+	if o.MinScore == 0 {
+		o.MinScore = 0.75
+	}
+	if o.TitleSimilarity == 0 {
+		o.TitleSimilarity = 0.8
+	}
+	if o.IngredientSimilarity == 0 {
+		o.IngredientSimilarity = 0.6
+	}
+	return o
+}
+
+// DuplicateMatch is a pair of recipes found to be duplicates, by their positions in the list given to FindDuplicates.
+type DuplicateMatch struct {
+	A, B int
+	// Score is how likely (0 to 1) it is that the two are the same recipe
+	Score   float64
+	Reasons []DuplicateReason
+}
+
+// DuplicateCluster is a group of recipes that are all (directly or through each other) duplicates. As recipes can be in
+// a cluster only through others (eg. A matches B and B matches C, but A and C are quite different), use DuplicatesOf to
+// find those that are direct duplicates of the copy being kept.
+type DuplicateCluster struct {
+	// Recipes are the positions of the recipes in the list given to FindDuplicates, in order
+	Recipes []int
+	// Matches are the pairs of recipes found to be duplicates, most certain first
+	Matches []DuplicateMatch
+	// Score is the lowest score of the cluster's matches
+	Score float64
+}
+
+// DuplicatesOf lists the positions of the cluster's recipes that were found to be duplicates of the recipe at position
+// i directly, in order.
+func (c DuplicateCluster) DuplicatesOf(i int) []int {
+	matched := make(map[int]bool)
+	for _, m := range c.Matches {
+		switch i {
+		case m.A:
+			matched[m.B] = true
+		case m.B:
+			matched[m.A] = true
+		}
+	}
+
+	var dupes []int
+	for _, n := range c.Recipes {
+		if matched[n] {
+			dupes = append(dupes, n)
+		}
+	}
+	return dupes
+}
+
+// dedupeFeatures are the parts of a recipe that are compared, worked out once for each recipe.
+type dedupeFeatures struct {
+	id          string
+	link        string
+	isbn        string
+	book        string
+	title       string
+	bigrams     map[string]int
+	ingredients map[string]bool
+}
+
+// FindDuplicates compares every pair of recipes, by their IDs, links (ignoring differences like "www." and tracking
+// parameters), book references, titles and ingredients, and groups those that are duplicates into clusters, most
+// certain first. Similar titles alone aren't enough to count as duplicates, and recipes from different books or links
+// are only counted by their IDs.
+func FindDuplicates(recipes []*Recipe, opts DedupeOptions) []DuplicateCluster {
+	opts = opts.withDefaults()
+
+	features := make([]dedupeFeatures, len(recipes))
+	for i, r := range recipes {
+		features[i] = newDedupeFeatures(r)
+	}
+
+	var matches []DuplicateMatch
+	for a := range features {
+		for b := a + 1; b < len(features); b++ {
+			if m, ok := compareForDuplicates(features[a], features[b], opts); ok {
+				m.A, m.B = a, b
+				matches = append(matches, m)
+			}
+		}
+	}
+
+	return clusterDuplicates(len(recipes), matches)
+}
+
+func newDedupeFeatures(r *Recipe) dedupeFeatures {
+	f := dedupeFeatures{
+		id:          r.ID,
+		link:        canonicalLink(r.Link),
+		title:       normalizedTitle(r.Title),
+		ingredients: make(map[string]bool),
+	}
+	f.bigrams = bigrams(f.title)
+
+	if book := r.Book(); book != nil {
+		f.isbn = book.ISBN13
+		// Only a specific recipe in a book counts as the same, not just the same book
+		if len(book.Pages) > 0 || book.RecipeNumber > 0 {
+			f.book = fmt.Sprintf("%s#%s#%d", book.ISBN13, book.Pages, book.RecipeNumber)
+		}
+	}
+
+	for _, ing := range r.IngredientList() {
+		if key := ingredientKey(ing); key != "" {
+			f.ingredients[key] = true
+		}
+	}
+	return f
+}
+
+func compareForDuplicates(a, b dedupeFeatures, opts DedupeOptions) (DuplicateMatch, bool) {
+	var m DuplicateMatch
+	strong := func(reason DuplicateReason, score float64) {
+		m.Reasons = append(m.Reasons, reason)
+		m.Score = max(m.Score, score)
+	}
+
+	if a.id != "" && a.id == b.id {
+		strong(SameID, sameIDScore)
+	}
+	if a.link != "" && a.link == b.link {
+		strong(SameLink, sameLinkScore)
+	}
+	if a.book != "" && a.book == b.book {
+		strong(SameBookRecipe, sameBookScore)
+	}
+
+	titleSim := diceSimilarity(a.bigrams, b.bigrams)
+	if a.title != "" && a.title == b.title {
+		titleSim = 1
+	}
+	if titleSim >= opts.TitleSimilarity {
+		m.Reasons = append(m.Reasons, SimilarTitle)
+	}
+
+	// Similar titles alone are weaker evidence than similar titles and ingredients, too weak to count by default
+	fuzzy := titleSim * 0.7
+	if len(a.ingredients) > 0 && len(b.ingredients) > 0 {
+		ingSim := jaccardSimilarity(a.ingredients, b.ingredients)
+		if ingSim >= opts.IngredientSimilarity {
+			m.Reasons = append(m.Reasons, SimilarIngredients)
+		}
+		fuzzy = titleSim*0.6 + ingSim*0.4
+	}
+	// Recipes from different books or web pages are different recipes, however alike they look
+	if differ(a.isbn, b.isbn) || differ(a.link, b.link) {
+		fuzzy = 0
+	}
+	m.Score = max(m.Score, fuzzy)
+
+	return m, m.Score >= opts.MinScore
+}
+
+// differ checks if two identifiers are both known and not the same.
+func differ(a, b string) bool {
+	return a != "" && b != "" && a != b
+}
+
+// clusterDuplicates joins recipes that are duplicates of each other, directly or not, into clusters.
+func clusterDuplicates(n int, matches []DuplicateMatch) []DuplicateCluster {
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var root func(int) int
+	root = func(i int) int {
+		if parent[i] != i {
+			parent[i] = root(parent[i])
+		}
+		return parent[i]
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	for _, m := range matches {
+		parent[root(m.A)] = root(m.B)
+	}
+
+	byRoot := make(map[int]*DuplicateCluster)
+	var clusters []*DuplicateCluster
+	for _, m := range matches {
+		c, ok := byRoot[root(m.A)]
+		if !ok {
+			c = &DuplicateCluster{Score: 1}
+			byRoot[root(m.A)] = c
+			clusters = append(clusters, c)
+		}
+		c.Matches = append(c.Matches, m)
+		c.Score = min(c.Score, m.Score)
+	}
+	for i := 0; i < n; i++ {
+		if c, ok := byRoot[root(i)]; ok {
+			c.Recipes = append(c.Recipes, i)
+		}
+	}
+
+	result := make([]DuplicateCluster, len(clusters))
+	for i, c := range clusters {
+		result[i] = *c
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Score > result[j].Score })
+	return result
+}
+
+// KeepRule compares two copies of a recipe, returning a positive number if a is the better one to keep, a negative
+// number if b is, or zero if it has no preference.
+type KeepRule func(a, b *Recipe) int
+
+// KeepRules are the rules BestCopy can use, by name.
+var KeepRules = map[string]KeepRule{
+	// favorite prefers copies marked as favorites
+	"favorite": func(a, b *Recipe) int { return boolPreference(a.Favorite, b.Favorite) },
+	// book prefers copies that refer to the book they're from
+	"book": func(a, b *Recipe) int { return boolPreference(a.Book() != nil, b.Book() != nil) },
+	// images prefers copies with more images
+	"images": func(a, b *Recipe) int { return len(a.Images) - len(b.Images) },
+	// complete prefers copies with more of their fields filled in
+	"complete": func(a, b *Recipe) int { return completeness(a) - completeness(b) },
+	// newest prefers copies added more recently
+	"newest": func(a, b *Recipe) int { return floatPreference(float64(a.Date), float64(b.Date)) },
+	// oldest prefers copies added earlier
+	"oldest": func(a, b *Recipe) int { return floatPreference(float64(b.Date), float64(a.Date)) },
+}
+
+// DefaultKeepRules are the names of the rules to use when none are given.
+var DefaultKeepRules = []string{"favorite", "complete", "images", "newest"}
+
+// BestCopy picks the copy of a recipe to keep from a set of duplicates, going by the first of the rules to prefer one
+// copy over another. It returns its position in recipes; if none of the rules can choose, the first copy is kept.
+func BestCopy(recipes []*Recipe, rules ...KeepRule) int {
+	best := 0
+	for i := 1; i < len(recipes); i++ {
+		for _, rule := range rules {
+			if pref := rule(recipes[i], recipes[best]); pref != 0 {
+				if pref > 0 {
+					best = i
+				}
+				break
+			}
+		}
+	}
+	return best
+}
+
+// completeness counts the recipe's fields that are filled in, and the lines of its ingredients and instructions.
+func completeness(r *Recipe) int {
+	n := len(strings.Split(string(r.Ingredients), "\n")) + len(strings.Split(string(r.Instructions), "\n"))
+	for _, field := range []string{r.Text, r.Link, r.Nutrition, r.Notes, string(r.Yield), string(r.PrepTime), string(r.CookTime), string(r.TotalTime)} {
+		if strings.TrimSpace(field) != "" {
+			n++
+		}
+	}
+	return n + len(r.Categories)
+}
+
+func boolPreference(a, b bool) int {
+	switch {
+	case a && !b:
+		return 1
+	case b && !a:
+		return -1
+	}
+	return 0
+}
+
+func floatPreference(a, b float64) int {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	}
+	return 0
+}
+
+// trackingParams are the query parameters that don't change which page a link is to, and trackingParamPrefixes the
+// families of them (eg. "utm_source", "utm_medium").
+var trackingParams = map[string]bool{"fbclid": true, "gclid": true, "ref": true, "source": true}
+var trackingParamPrefixes = []string{"utm_", "mc_"}
+
+// canonicalLink reduces a web link to the parts that identify the page, eg. "https://www.example.com/pie/?utm_source=x"
+// is "example.com/pie". Links that aren't to a specific web page give an empty string.
+func canonicalLink(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, prefix := range []string{"www.", "m.", "amp."} {
+		host = strings.TrimPrefix(host, prefix)
+	}
+	p := strings.TrimSuffix(u.EscapedPath(), "/")
+	p = strings.TrimSuffix(p, "/amp")
+	if p == "" {
+		return ""
+	}
+
+	query := u.Query()
+	for key := range query {
+		if isTrackingParam(strings.ToLower(key)) {
+			query.Del(key)
+		}
+	}
+	if len(query) > 0 {
+		return host + p + "?" + query.Encode()
+	}
+	return host + p
+}
+
+func isTrackingParam(key string) bool {
+	if trackingParams[key] {
+		return true
+	}
+	for _, prefix := range trackingParamPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// titleFillerWords are words that are often added to, or left out of, the same recipe's title.
+var titleFillerWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "with": true, "my": true, "our": true, "recipe": true,
+	"easy": true, "quick": true, "simple": true, "best": true, "perfect": true, "ultimate": true, "homemade": true,
+	"classic": true, "favourite": true, "favorite": true, "ever": true,
+}
+
+// normalizedTitle lowercases the title, removes accents and punctuation, and leaves out filler words and plurals.
+func normalizedTitle(title string) string {
+	plain, _, _ := transform.String(removeAccents, strings.ToLower(title))
+	var words []string
+	for _, w := range wordFinder.FindAllString(plain, -1) {
+		if !titleFillerWords[w] {
+			words = append(words, singular(w))
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// ingredientKey is the main word of the ingredient's name, eg. "shallot" for "2 banana shallots", which is how
+// different versions of the same recipe usually agree.
+func ingredientKey(ing Ingredient) string {
+	words := wordFinder.FindAllString(strings.ToLower(ing.Name), -1)
+	for i := len(words) - 1; i >= 0; i-- {
+		w := singular(words[i])
+		if !genericNouns[w] || i == 0 {
+			return w
+		}
+	}
+	return ""
+}
+
+func bigrams(s string) map[string]int {
+	grams := make(map[string]int)
+	runes := []rune(s)
+	for i := 0; i+1 < len(runes); i++ {
+		grams[string(runes[i:i+2])]++
+	}
+	return grams
+}
+
+// diceSimilarity is the Sørensen–Dice coefficient of two sets of bigrams: 1 if they're the same, 0 if they share none.
+func diceSimilarity(a, b map[string]int) float64 {
+	total, shared := 0, 0
+	for gram, n := range a {
+		total += n
+		shared += min(n, b[gram])
+	}
+	for _, n := range b {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(2*shared) / float64(total)
+}
+
+// jaccardSimilarity is the proportion of all the items in either set that are in both.
+func jaccardSimilarity(a, b map[string]bool) float64 {
+	shared := 0
+	for item := range a {
+		if b[item] {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
@@ -0,0 +1,154 @@
+package mela_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	book := func(title, isbn, pages string) *mela.Recipe {
+		r := &mela.Recipe{Title: title}
+		if err := r.SetBook(isbn, mela.MustParsePages(pages), 0); err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	recipes := []*mela.Recipe{
+		// 0-1: the same link, written differently
+		{ID: "x1", Title: "Shakshuka", Link: "https://www.example.com/shakshuka/?utm_source=newsletter"},
+		{ID: "x2", Title: "Baked eggs", Link: "http://example.com/shakshuka"},
+		// 2-3: similar titles and ingredients
+		{ID: "x3", Title: "The Best Banana Bread", Ingredients: "3 ripe bananas\n250g plain flour\n100g butter\n2 eggs\n150g sugar"},
+		{ID: "x4", Title: "Banana bread", Ingredients: "4 bananas\n2 cups flour\n1 stick unsalted butter\n2 large eggs\n3/4 cup brown sugar\n1 tsp cinnamon"},
+		// 4: same title as 2-3, but a different recipe
+		{ID: "x5", Title: "Banana bread", Ingredients: "1 loaf of bread\n2 bananas\nHoney"},
+		// 5-6: the same recipe in a book (which gives them the same ID)
+		book("Lemon tart", "123456789X", "42"),
+		book("Tarte au citron", "9781234567897", "42"),
+		// 7: the same book, a different recipe
+		book("Lemon cake", "123456789X", "43"),
+		// 8-9: the same ID
+		{ID: "x10", Title: "Pancakes"},
+		{ID: "x10", Title: "Crêpes"},
+	}
+
+	clusters := mela.FindDuplicates(recipes, mela.DedupeOptions{})
+
+	var got [][]int
+	reasons := make(map[int][]mela.DuplicateReason)
+	for _, c := range clusters {
+		got = append(got, c.Recipes)
+		if len(c.Matches) > 0 {
+			reasons[c.Recipes[0]] = c.Matches[0].Reasons
+		}
+		if c.Score <= 0 || c.Score > 1 {
+			t.Errorf("Cluster %v has an invalid score: %f", c.Recipes, c.Score)
+		}
+	}
+	want := [][]int{{5, 6}, {8, 9}, {0, 1}, {2, 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Incorrect clusters: want = %v, got = %v", want, got)
+	}
+
+	wantReasons := map[int][]mela.DuplicateReason{
+		8: {mela.SameID},
+		0: {mela.SameLink},
+		5: {mela.SameID, mela.SameBookRecipe},
+		2: {mela.SimilarTitle, mela.SimilarIngredients},
+	}
+	if !reflect.DeepEqual(reasons, wantReasons) {
+		t.Errorf("Incorrect reasons: want = %v, got = %v", wantReasons, reasons)
+	}
+}
+
+func TestDuplicateCluster_DuplicatesOf(t *testing.T) {
+	recipes := []*mela.Recipe{
+		{ID: "a", Title: "Shakshuka", Link: "https://example.com/shakshuka"},
+		{ID: "b", Title: "Eggs in tomato sauce", Link: "https://example.com/shakshuka?ref=home"},
+		{ID: "b", Title: "Chocolate mousse"},
+		// Only tracking parameters are ignored, so these are different pages
+		{ID: "c", Title: "Lemon tart", Link: "https://example.com/recipe?reference=1"},
+		{ID: "d", Title: "Roast chicken", Link: "https://example.com/recipe?reference=2"},
+	}
+
+	clusters := mela.FindDuplicates(recipes, mela.DedupeOptions{})
+	if len(clusters) != 1 || !reflect.DeepEqual(clusters[0].Recipes, []int{0, 1, 2}) {
+		t.Fatalf("Expected one cluster of recipes 0, 1 and 2, got %#v", clusters)
+	}
+
+	for i, want := range [][]int{{1}, {0, 2}, {1}} {
+		if got := clusters[0].DuplicatesOf(i); !reflect.DeepEqual(got, want) {
+			t.Errorf("Incorrect duplicates of %d: want = %v, got = %v", i, want, got)
+		}
+	}
+}
+
+func TestBestCopy(t *testing.T) {
+	recipes := []*mela.Recipe{
+		{Title: "Plain", Date: 200},
+		{Title: "Pictured", Images: []mela.B64Image{{}}, Date: 100},
+		{Title: "Newest", Date: 300},
+	}
+
+	tests := []struct {
+		rules []string
+		want  int
+	}{
+		{[]string{"images"}, 1},
+		{[]string{"newest"}, 2},
+		{[]string{"oldest"}, 1},
+		{[]string{"favorite", "complete"}, 0},
+		{mela.DefaultKeepRules, 1},
+	}
+	for _, tt := range tests {
+		var rules []mela.KeepRule
+		for _, name := range tt.rules {
+			rules = append(rules, mela.KeepRules[name])
+		}
+		if got := mela.BestCopy(recipes, rules...); got != tt.want {
+			t.Errorf("For rules %v, wanted %s, got %s", tt.rules, recipes[tt.want].Title, recipes[got].Title)
+		}
+	}
+}
+
+func TestFindDuplicates_FalsePositives(t *testing.T) {
+	book := func(title, isbn string) *mela.Recipe {
+		r := &mela.Recipe{Title: title}
+		if err := r.SetBook(isbn, nil, 0); err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		recipes []*mela.Recipe
+	}{
+		{"different books", []*mela.Recipe{
+			book("Classic Chocolate Cake", "123456789X"),
+			book("Easy chocolate cake", "9780241953242"),
+		}},
+		{"different links", []*mela.Recipe{
+			{Title: "Pancakes", Link: "https://a.com/pancakes"},
+			{Title: "The best pancakes", Link: "https://b.com/pancakes"},
+		}},
+		{"different links, same ingredients", []*mela.Recipe{
+			{Title: "Pancakes", Link: "https://a.com/pancakes", Ingredients: "2 eggs\n100g flour\n300ml milk"},
+			{Title: "Pancakes", Link: "https://b.com/pancakes", Ingredients: "2 eggs\n100g flour\n300ml milk"},
+		}},
+		{"only the title", []*mela.Recipe{
+			{Title: "Pancakes"},
+			{Title: "Pancakes", Ingredients: "2 eggs\n100g flour\n300ml milk"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if clusters := mela.FindDuplicates(tt.recipes, mela.DedupeOptions{}); len(clusters) != 0 {
+				t.Errorf("Expected no duplicates, got %#v", clusters)
+			}
+		})
+	}
+}
@@ -1,19 +1,49 @@
 package mela
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type MaybeDuration string
 
+// DurationStyle selects how FormatDuration writes a duration back out as a MaybeDuration.
+type DurationStyle int
+
+const (
+	// DurationStyleLong writes durations like "1 hr 30 mins"
+	DurationStyleLong DurationStyle = iota
+	// DurationStyleMinutes writes durations like "90 min"
+	DurationStyleMinutes
+	// DurationStyleISO8601 writes durations like "PT1H30M"
+	DurationStyleISO8601
+)
+
+var ErrInvalidISO8601Duration = errors.New("the given string is not a valid ISO 8601 duration")
+
+// iso8601Shape matches strings made up only of the characters an ISO 8601 duration can use, so text that merely starts
+// with a P (eg. "Prep 10 mins") isn't mistaken for one.
+var iso8601Shape = regexp.MustCompile(`^(?i)P[\d.,YMWDTHS]*$`)
+
 func (m MaybeDuration) Parse() (*time.Duration, error) {
 	if m == "" {
 		return nil, nil
 	}
 
+	if iso8601Shape.MatchString(strings.TrimSpace(string(m))) {
+		return parseISO8601Duration(string(m))
+	}
+
 	in := strings.ReplaceAll(string(m), "hours", "h")
 	in = strings.ReplaceAll(in, "hour", "h")
+	in = strings.ReplaceAll(in, "hrs", "h")
+	in = strings.ReplaceAll(in, "hr", "h")
+	in = strings.ReplaceAll(in, "minutes", "m")
+	in = strings.ReplaceAll(in, "minute", "m")
 	in = strings.ReplaceAll(in, "mins", "m")
 	in = strings.ReplaceAll(in, "min", "m")
 	in = strings.ReplaceAll(in, ".", "")
@@ -26,3 +56,99 @@ func (m MaybeDuration) Parse() (*time.Duration, error) {
 
 	return &d, nil
 }
+
+// FormatDuration writes the given duration, rounded to the nearest minute, in the given style.
+func FormatDuration(d time.Duration, style DurationStyle) MaybeDuration {
+	mins := int64(d.Round(time.Minute) / time.Minute)
+
+	switch style {
+	case DurationStyleMinutes:
+		return MaybeDuration(fmt.Sprintf("%d min", mins))
+	case DurationStyleISO8601:
+		return MaybeDuration(formatISO8601Duration(mins))
+	default:
+		return MaybeDuration(formatLongDuration(mins))
+	}
+}
+
+func formatLongDuration(mins int64) string {
+	hours, mins := mins/60, mins%60
+
+	var parts []string
+	switch {
+	case hours == 1:
+		parts = append(parts, "1 hr")
+	case hours > 1:
+		parts = append(parts, fmt.Sprintf("%d hrs", hours))
+	}
+
+	switch {
+	case mins == 1:
+		parts = append(parts, "1 min")
+	case mins > 1 || hours == 0:
+		parts = append(parts, fmt.Sprintf("%d mins", mins))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func formatISO8601Duration(mins int64) string {
+	hours, mins := mins/60, mins%60
+
+	out := "PT"
+	if hours > 0 {
+		out += fmt.Sprintf("%dH", hours)
+	}
+	if mins > 0 || hours == 0 {
+		out += fmt.Sprintf("%dM", mins)
+	}
+
+	return out
+}
+
+// parseISO8601Duration handles the PnW and PnYnMnDTnHnMnS forms of ISO 8601 durations, taking years and months to be
+// 365 and 30 days long respectively.
+func parseISO8601Duration(str string) (*time.Duration, error) {
+	in := strings.ToUpper(strings.TrimSpace(str))
+	if len(in) < 3 || in[0] != 'P' {
+		return nil, ErrInvalidISO8601Duration
+	}
+
+	units := map[bool]map[byte]time.Duration{
+		false: {'Y': 365 * 24 * time.Hour, 'M': 30 * 24 * time.Hour, 'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour},
+		true:  {'H': time.Hour, 'M': time.Minute, 'S': time.Second},
+	}
+
+	var d time.Duration
+	inTime := false
+	num := ""
+	for i := 1; i < len(in); i++ {
+		c := in[i]
+		switch {
+		case c == 'T':
+			if inTime || num != "" {
+				return nil, ErrInvalidISO8601Duration
+			}
+			inTime = true
+		case (c >= '0' && c <= '9') || c == '.' || c == ',':
+			num += string(c)
+		default:
+			unit, ok := units[inTime][c]
+			if !ok || num == "" {
+				return nil, ErrInvalidISO8601Duration
+			}
+			n, err := strconv.ParseFloat(strings.ReplaceAll(num, ",", "."), 64)
+			if err != nil {
+				return nil, ErrInvalidISO8601Duration
+			}
+			d += time.Duration(n * float64(unit))
+			num = ""
+		}
+	}
+
+	if num != "" || in[len(in)-1] == 'T' {
+		return nil, ErrInvalidISO8601Duration
+	}
+
+	return &d, nil
+}
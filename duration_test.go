@@ -1,6 +1,7 @@
 package mela
 
 import (
+	"errors"
 	"testing"
 	"time"
 )
@@ -16,6 +17,8 @@ func Test_Parse(t *testing.T) {
 	hour2 := 2 * time.Hour
 	min1 := time.Minute
 	min2 := 2 * time.Minute
+	hour1min30 := 90 * time.Minute
+	day1 := 24 * time.Hour
 
 	tests := []test{
 		{"", nil, false},
@@ -40,7 +43,25 @@ func Test_Parse(t *testing.T) {
 		{"2 mins", &min2, false},
 		{"2 mins.", &min2, false},
 
+		{"1 hr", &hour1, false},
+		{"2 hrs", &hour2, false},
+		{"1 minute", &min1, false},
+		{"2 minutes", &min2, false},
+		{"1 hr 30 mins", &hour1min30, false},
+
+		{"PT1H", &hour1, false},
+		{"PT2M", &min2, false},
+		{"PT1H30M", &hour1min30, false},
+		{"pt90m", &hour1min30, false},
+		{"PT1.5H", &hour1min30, false},
+		{"P1D", &day1, false},
+
 		{"nope", nil, true},
+		{"P", nil, true},
+		{"PT", nil, true},
+		{"P1H", nil, true},
+		{"PT1", nil, true},
+		{"Prep 10 mins", nil, true},
 	}
 	for _, test := range tests {
 		got, err := test.input.Parse()
@@ -55,4 +76,48 @@ func Test_Parse(t *testing.T) {
 			t.Errorf("Incorrect output: want = %v, got = %v", test.want, got)
 		}
 	}
+
+	if _, err := MaybeDuration("Prep 10 mins").Parse(); errors.Is(err, ErrInvalidISO8601Duration) {
+		t.Error("Expected text starting with a P not to be parsed as an ISO 8601 duration")
+	}
+}
+
+func Test_FormatDuration(t *testing.T) {
+	type test struct {
+		input time.Duration
+		style DurationStyle
+		want  MaybeDuration
+	}
+
+	tests := []test{
+		{90 * time.Minute, DurationStyleLong, "1 hr 30 mins"},
+		{time.Hour, DurationStyleLong, "1 hr"},
+		{2*time.Hour + time.Minute, DurationStyleLong, "2 hrs 1 min"},
+		{45 * time.Minute, DurationStyleLong, "45 mins"},
+		{0, DurationStyleLong, "0 mins"},
+		{89*time.Minute + 40*time.Second, DurationStyleLong, "1 hr 30 mins"},
+
+		{90 * time.Minute, DurationStyleMinutes, "90 min"},
+		{0, DurationStyleMinutes, "0 min"},
+
+		{90 * time.Minute, DurationStyleISO8601, "PT1H30M"},
+		{2 * time.Hour, DurationStyleISO8601, "PT2H"},
+		{0, DurationStyleISO8601, "PT0M"},
+	}
+	for _, test := range tests {
+		got := FormatDuration(test.input, test.style)
+		if got != test.want {
+			t.Errorf("Incorrect format for %v: want = %s, got = %s", test.input, test.want, got)
+			continue
+		}
+
+		roundTrip, err := got.Parse()
+		if err != nil {
+			t.Errorf("Unable to parse formatted %s: %v", got, err)
+			continue
+		}
+		if *roundTrip != test.input.Round(time.Minute) {
+			t.Errorf("Incorrect round trip for %s: want = %v, got = %v", got, test.input.Round(time.Minute), *roundTrip)
+		}
+	}
 }
@@ -0,0 +1,240 @@
+package mela
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+var ErrRecipeNotFound = errors.New("no recipe in the bundle has that ID or filename")
+var ErrRecipeExists = errors.New("a recipe in the bundle already has that ID")
+
+// EditableBundle is an existing .melarecipes file that recipes can be added to, replaced in, or deleted from. Changes
+// are only written when it's saved, and then to a new file that replaces the original once complete, so the original
+// is never left half-written. Recipes that aren't changed are copied across as they are, without being parsed. Once it's
+// saved or closed, it can't be used any more.
+type EditableBundle struct {
+	filename     string
+	src          *os.File
+	entries      []*bundleEntry
+	reproducible bool
+	closed       bool
+}
+
+// bundleEntry is a recipe in an editable bundle: either a file in the original bundle, or a recipe to be written.
+type bundleEntry struct {
+	filename string
+	id       string
+	zipped   *zip.File
+	recipe   *Recipe
+}
+
+// OpenEditableBundle opens the .melarecipes file for editing. If it doesn't exist, it will be created when saved.
+func OpenEditableBundle(filename string) (*EditableBundle, error) {
+	eb := &EditableBundle{filename: filename}
+
+	src, err := os.Open(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return eb, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	eb.src = src
+
+	info, err := src.Stat()
+	if err != nil {
+		eb.src.Close()
+		return nil, err
+	}
+	zr, err := zip.NewReader(src, info.Size())
+	if err != nil {
+		eb.src.Close()
+		return nil, err
+	}
+	// Each recipe is read once, to find its ID, and not kept
+	for _, zf := range zr.File {
+		r, err := parseZippedRecipe(zf)
+		if err != nil {
+			eb.src.Close()
+			return nil, fmt.Errorf("unable to parse '%s': %w", zf.Name, err)
+		}
+		eb.entries = append(eb.entries, &bundleEntry{filename: r.Filename, id: r.ID, zipped: zf})
+	}
+
+	return eb, nil
+}
+
+// SetReproducible makes the saved bundle reproducible, as Recipes.SetReproducible does. Every recipe is rewritten when
+// the bundle is saved.
+func (eb *EditableBundle) SetReproducible() {
+	eb.reproducible = true
+}
+
+// find returns the position of the recipe with the given ID or, failing that, filename.
+func (eb *EditableBundle) find(key string) int {
+	for i, e := range eb.entries {
+		if e.id != "" && e.id == key {
+			return i
+		}
+	}
+	for i, e := range eb.entries {
+		if e.filename == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Filenames lists the filenames of the recipes in the bundle, in order.
+func (eb *EditableBundle) Filenames() []string {
+	var names []string
+	for _, e := range eb.entries {
+		names = append(names, e.filename)
+	}
+	return names
+}
+
+// Get returns the recipe with the given ID or, failing that, filename. Changes to it aren't kept unless it's given to
+// Replace.
+func (eb *EditableBundle) Get(key string) (*Recipe, error) {
+	if eb.closed {
+		return nil, fs.ErrClosed
+	}
+	i := eb.find(key)
+	if i < 0 {
+		return nil, ErrRecipeNotFound
+	}
+	if e := eb.entries[i]; e.zipped != nil {
+		return parseZippedRecipe(e.zipped)
+	}
+	r := *eb.entries[i].recipe
+	return &r, nil
+}
+
+// Add adds the recipe to the end of the bundle. It's an error to add a recipe with the same ID as one already in the
+// bundle; a recipe with the same filename as another is given a numbered suffix, as with Recipes.Add.
+func (eb *EditableBundle) Add(r *Recipe) error {
+	if eb.closed {
+		return fs.ErrClosed
+	}
+	if eb.hasID(r.ID, -1) {
+		return ErrRecipeExists
+	}
+
+	eb.entries = append(eb.entries, eb.newEntry(r, -1))
+	return nil
+}
+
+// Replace puts the recipe in the place of the one with the given ID or, failing that, filename. It's an error for the
+// new recipe to have the same ID as a different one in the bundle.
+func (eb *EditableBundle) Replace(key string, r *Recipe) error {
+	if eb.closed {
+		return fs.ErrClosed
+	}
+	i := eb.find(key)
+	if i < 0 {
+		return ErrRecipeNotFound
+	}
+	if eb.hasID(r.ID, i) {
+		return ErrRecipeExists
+	}
+	eb.entries[i] = eb.newEntry(r, i)
+	return nil
+}
+
+// hasID checks if any recipe, other than the one at position except, has the given ID.
+func (eb *EditableBundle) hasID(id string, except int) bool {
+	if id == "" {
+		return false
+	}
+	for i, e := range eb.entries {
+		if i != except && e.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Delete removes the recipe with the given ID or, failing that, filename.
+func (eb *EditableBundle) Delete(key string) error {
+	if eb.closed {
+		return fs.ErrClosed
+	}
+	i := eb.find(key)
+	if i < 0 {
+		return ErrRecipeNotFound
+	}
+	eb.entries = append(eb.entries[:i], eb.entries[i+1:]...)
+	return nil
+}
+
+// newEntry makes an entry for the recipe to go at position at (or the end, if -1), with the filename it'll be saved as:
+// a numbered suffix is added if it's the same as any other recipe's, as with Recipes.Add.
+func (eb *EditableBundle) newEntry(r *Recipe, at int) *bundleEntry {
+	names := make(map[string]bool)
+	for i, e := range eb.entries {
+		if i != at {
+			names[e.filename] = true
+		}
+	}
+
+	copied := *r
+	copied.Filename = uniqueName(names, r.Filename)
+	return &bundleEntry{filename: copied.Filename, id: r.ID, recipe: &copied}
+}
+
+// Save writes the edited bundle over the original file, and closes it. If it can't be saved, it's left open, so it can
+// be tried again.
+func (eb *EditableBundle) Save() error {
+	if eb.closed {
+		return fs.ErrClosed
+	}
+
+	tmp, err := createReplacement(eb.filename)
+	if err != nil {
+		return err
+	}
+
+	dst := NewRecipesWriter(tmp)
+	if eb.reproducible {
+		if err := dst.SetReproducible(); err != nil {
+			discardReplacement(tmp)
+			return err
+		}
+	}
+	for _, e := range eb.entries {
+		if e.zipped != nil {
+			err = dst.addZipped(e.zipped)
+		} else {
+			err = dst.Add(e.recipe)
+		}
+		if err != nil {
+			discardReplacement(tmp)
+			return fmt.Errorf("unable to write '%s': %w", e.filename, err)
+		}
+	}
+	if err := dst.Close(); err != nil {
+		discardReplacement(tmp)
+		return err
+	}
+
+	if err := commitReplacement(tmp, eb.filename); err != nil {
+		return err
+	}
+	return eb.Close()
+}
+
+// Close closes the original file, discarding any changes that haven't been saved.
+func (eb *EditableBundle) Close() error {
+	eb.closed = true
+	eb.entries = nil
+	if eb.src == nil {
+		return nil
+	}
+	err := eb.src.Close()
+	eb.src = nil
+	return err
+}
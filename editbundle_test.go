@@ -0,0 +1,210 @@
+package mela_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func copyFixture(t *testing.T, fixture string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("fixtures", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), fixture)
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestEditableBundle(t *testing.T) {
+	filename := copyFixture(t, "a+b.melarecipes")
+
+	eb, err := mela.OpenEditableBundle(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := eb.Filenames(); !reflect.DeepEqual(got, []string{"B title", "A title"}) {
+		t.Errorf("Incorrect recipes: %v", got)
+	}
+
+	a, err := eb.Get("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Title = "A better title"
+	if err := eb.Replace("A title", a); err != nil {
+		t.Errorf("Unable to replace by filename: %v", err)
+	}
+	if err := eb.Delete("b"); err != nil {
+		t.Errorf("Unable to delete by ID: %v", err)
+	}
+
+	c, err := mela.Open("fixtures/c.melarecipe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c[0].Filename = "C title"
+	if err := eb.Add(c[0]); err != nil {
+		t.Errorf("Unable to add: %v", err)
+	}
+	if err := eb.Add(c[0]); !errors.Is(err, mela.ErrRecipeExists) {
+		t.Errorf("Expected adding a recipe twice to fail, got %v", err)
+	}
+	if err := eb.Delete("missing"); !errors.Is(err, mela.ErrRecipeNotFound) {
+		t.Errorf("Expected deleting a missing recipe to fail, got %v", err)
+	}
+
+	if err := eb.Save(); err != nil {
+		t.Fatalf("Unable to save: %v", err)
+	}
+
+	got, err := mela.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, r := range got {
+		titles = append(titles, r.Title)
+	}
+	if want := []string{"A better title", "C title"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("Incorrect saved recipes: want = %v, got = %v", want, titles)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(filename)); len(entries) != 1 {
+		t.Errorf("Saving left %d files behind", len(entries)-1)
+	}
+}
+
+func TestEditableBundle_New(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "new.melarecipes")
+
+	eb, err := mela.OpenEditableBundle(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipes, err := mela.Open("fixtures/a.melarecipe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := eb.Add(recipes[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := eb.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := mela.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Wanted 1 recipe, got %d", len(got))
+	}
+	EnsureRecipe(t, got[0], "a")
+}
+
+func TestEditableBundle_Close(t *testing.T) {
+	filename := copyFixture(t, "a+b.melarecipes")
+	before, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eb, err := mela.OpenEditableBundle(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := eb.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := eb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := eb.Save(); !errors.Is(err, fs.ErrClosed) {
+		t.Errorf("Expected saving after closing to fail, got %v", err)
+	}
+
+	after, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Error("Closing without saving changed the bundle")
+	}
+}
+
+func TestEditableBundle_ClashingFilename(t *testing.T) {
+	filename := copyFixture(t, "a+b.melarecipes")
+
+	eb, err := mela.OpenEditableBundle(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := mela.Open("fixtures/c.melarecipe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c[0].Filename = "A title"
+	if err := eb.Add(c[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"B title", "A title", "A title-2"}
+	if got := eb.Filenames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Incorrect filenames: want = %v, got = %v", want, got)
+	}
+	if got, err := eb.Get("A title-2"); err != nil || got.ID != c[0].ID {
+		t.Errorf("Expected the added recipe by its new filename, got %v (%v)", got, err)
+	}
+
+	if err := eb.Save(); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := mela.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := recipeFilenames(saved); !reflect.DeepEqual(got, want) {
+		t.Errorf("Incorrect saved filenames: want = %v, got = %v", want, got)
+	}
+}
+
+func TestEditableBundle_UseAfterSave(t *testing.T) {
+	filename := copyFixture(t, "a+b.melarecipes")
+
+	eb, err := mela.OpenEditableBundle(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := eb.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := eb.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := eb.Save(); !errors.Is(err, fs.ErrClosed) {
+		t.Errorf("Expected saving twice to fail, got %v", err)
+	}
+	if err := eb.Add(&mela.Recipe{ID: "new"}); !errors.Is(err, fs.ErrClosed) {
+		t.Errorf("Expected adding after saving to fail, got %v", err)
+	}
+	if err := eb.Close(); err != nil {
+		t.Errorf("Unable to close after saving: %v", err)
+	}
+
+	got, err := mela.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "b" {
+		t.Errorf("Expected only recipe b to be saved, got %d recipes", len(got))
+	}
+}
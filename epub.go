@@ -0,0 +1,330 @@
+package mela
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/epub/*.tmpl
+var epubTemplateFiles embed.FS
+
+var epubPages = htmltemplate.Must(htmltemplate.New("").Funcs(htmlFuncs).ParseFS(epubTemplateFiles,
+	"templates/epub/*.xhtml.tmpl", "templates/epub/style.css.tmpl"))
+
+var epubPackage = template.Must(template.New("").Funcs(template.FuncMap{"xml": xmlEscape}).ParseFS(epubTemplateFiles,
+	"templates/epub/content.opf.tmpl", "templates/epub/container.xml.tmpl"))
+
+// epubOtherChapter holds the recipes that don't fit in any other chapter, and comes last.
+const epubOtherChapter = "Other recipes"
+
+var ErrNoRecipes = errors.New("no recipes were given")
+
+// EPUBGrouping is how recipes are split into the chapters of an EPUB.
+type EPUBGrouping string
+
+const (
+	// EPUBByCategory makes a chapter for each recipe's first category
+	EPUBByCategory EPUBGrouping = "category"
+	// EPUBBySource makes a chapter for each book or website, with book recipes in page order
+	EPUBBySource EPUBGrouping = "source"
+)
+
+// EPUBOptions describes the book to make from a set of recipes.
+type EPUBOptions struct {
+	Title       string
+	Author      string
+	Description string
+	// Language is a BCP 47 language tag, English ("en") if not given
+	Language string
+	// Identifier uniquely identifies the book, eg. "urn:uuid:…". One is derived from the recipes if not given
+	Identifier string
+	GroupBy    EPUBGrouping
+	// Cover is the cover image; the first recipe image is used if not given
+	Cover B64Image
+	// Modified is when the book was last changed, now if not given
+	Modified time.Time
+}
+
+type epubBook struct {
+	EPUBOptions
+	Modified string
+	Cover    string
+	Subjects []string
+	Chapters []*epubChapter
+	Images   []epubImage
+}
+
+type epubChapter struct {
+	ID       string
+	Title    string
+	Filename string
+	Language string
+	Recipes  []*epubRecipe
+}
+
+type epubRecipe struct {
+	HTMLRecipePage
+	Anchor     string
+	Href       string
+	ImagePaths []string
+	sortKey    string
+}
+
+// epubFile is a file in the EPUB made from one of its templates.
+type epubFile struct {
+	name      string
+	templates interface {
+		ExecuteTemplate(io.Writer, string, any) error
+	}
+	template string
+	data     any
+}
+
+type epubImage struct {
+	ID   string
+	Href string
+	data []byte
+}
+
+// WriteEPUB writes the given recipes as an EPUB 3 book, grouped into chapters, with a table of contents and a cover.
+// Images are optimised (see B64Image.Optimize) before being embedded, and any that can't be read are left out.
+func WriteEPUB(w io.Writer, recipes []*Recipe, opts EPUBOptions) error {
+	if len(recipes) == 0 {
+		return ErrNoRecipes
+	}
+
+	book := &epubBook{EPUBOptions: opts}
+	if book.Title == "" {
+		book.Title = "Recipes"
+	}
+	if book.Language == "" {
+		book.Language = "en"
+	}
+	if book.Identifier == "" {
+		book.Identifier = epubIdentifier(recipes)
+	}
+	modified := opts.Modified
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	book.Modified = modified.UTC().Format(time.RFC3339)
+
+	cover := opts.Cover
+	book.Chapters = epubChapters(recipes, opts.GroupBy)
+	for _, ch := range book.Chapters {
+		ch.Language = book.Language
+		book.Subjects = append(book.Subjects, ch.Title)
+		for _, er := range ch.Recipes {
+			for i, img := range er.Images {
+				optimized, err := img.Optimize()
+				if err != nil {
+					continue
+				}
+				href := fmt.Sprintf("images/%s-%d.jpg", er.Anchor, i+1)
+				book.Images = append(book.Images, epubImage{ID: fmt.Sprintf("img-%s-%d", er.Anchor, i+1), Href: href, data: optimized})
+				er.ImagePaths = append(er.ImagePaths, href)
+				if cover == nil {
+					cover = img
+				}
+			}
+		}
+	}
+
+	var coverData []byte
+	if cover != nil {
+		var err error
+		if coverData, err = cover.OptimizeWithConfig(1600, 2400); err != nil {
+			return fmt.Errorf("unable to read cover image: %w", err)
+		}
+		book.Cover = "images/cover.jpg"
+	}
+
+	zw := zip.NewWriter(w)
+
+	// The mimetype must come first, and be uncompressed
+	mw, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := mw.Write([]byte("application/epub+zip")); err != nil {
+		return err
+	}
+
+	files := []epubFile{
+		{"META-INF/container.xml", epubPackage, "container.xml", nil},
+		{"OEBPS/content.opf", epubPackage, "content.opf", book},
+		{"OEBPS/nav.xhtml", epubPages, "nav.xhtml", book},
+		{"OEBPS/style.css", epubPages, "style.css", nil},
+	}
+	if book.Cover != "" {
+		files = append(files, epubFile{"OEBPS/cover.xhtml", epubPages, "cover.xhtml", book})
+	}
+	for _, ch := range book.Chapters {
+		files = append(files, epubFile{path.Join("OEBPS", ch.Filename), epubPages, "chapter.xhtml", ch})
+	}
+
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		// html/template escapes XML declarations, so they're written here instead
+		if path.Ext(f.name) == ".xhtml" {
+			if _, err := io.WriteString(fw, xml.Header); err != nil {
+				return err
+			}
+		}
+		if err := f.templates.ExecuteTemplate(fw, f.template, f.data); err != nil {
+			return fmt.Errorf("unable to write %s: %w", f.name, err)
+		}
+	}
+
+	images := book.Images
+	if coverData != nil {
+		images = append(images, epubImage{Href: book.Cover, data: coverData})
+	}
+	for _, img := range images {
+		// JPEGs are already compressed
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: path.Join("OEBPS", img.Href), Method: zip.Store})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(img.data); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// SaveEPUB writes the given recipes as an EPUB 3 book to the given file. See WriteEPUB.
+func SaveEPUB(filename string, recipes []*Recipe, opts EPUBOptions) error {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if err := WriteEPUB(f, recipes, opts); err != nil {
+		f.Close()
+		os.Remove(filename)
+		return err
+	}
+	return f.Close()
+}
+
+// epubChapters groups the recipes into chapters, in alphabetical order (but with epubOtherChapter last), with the recipes in each ordered by their
+// position in their book, or by title.
+func epubChapters(recipes []*Recipe, groupBy EPUBGrouping) []*epubChapter {
+	byTitle := make(map[string]*epubChapter)
+	var chapters []*epubChapter
+
+	for _, r := range recipes {
+		title, sortKey := epubGroup(r, groupBy)
+		ch, ok := byTitle[title]
+		if !ok {
+			ch = &epubChapter{Title: title}
+			byTitle[title] = ch
+			chapters = append(chapters, ch)
+		}
+		ch.Recipes = append(ch.Recipes, &epubRecipe{HTMLRecipePage: HTMLRecipePage{Recipe: r}, sortKey: sortKey})
+	}
+
+	sort.SliceStable(chapters, func(i, j int) bool {
+		if (chapters[i].Title == epubOtherChapter) != (chapters[j].Title == epubOtherChapter) {
+			return chapters[j].Title == epubOtherChapter
+		}
+		return strings.ToLower(chapters[i].Title) < strings.ToLower(chapters[j].Title)
+	})
+
+	n := 0
+	for i, ch := range chapters {
+		ch.ID = fmt.Sprintf("chapter-%d", i+1)
+		ch.Filename = ch.ID + ".xhtml"
+
+		sort.SliceStable(ch.Recipes, func(i, j int) bool { return ch.Recipes[i].sortKey < ch.Recipes[j].sortKey })
+		for _, er := range ch.Recipes {
+			n++
+			er.Anchor = fmt.Sprintf("recipe-%d", n)
+			er.Href = ch.Filename + "#" + er.Anchor
+		}
+	}
+
+	return chapters
+}
+
+// epubGroup picks the chapter a recipe belongs in, and a key to sort it within that chapter.
+func epubGroup(r *Recipe, groupBy EPUBGrouping) (string, string) {
+	titleKey := strings.ToLower(r.Title)
+
+	if groupBy == EPUBBySource {
+		book := r.Book()
+		if book == nil {
+			if r.Link == "" {
+				return epubOtherChapter, titleKey
+			}
+			return sourceTitle(r.Link), titleKey
+		}
+
+		title := r.Link
+		if title == "" || strings.Contains(r.Link, "://") {
+			title = "ISBN " + book.ISBN13
+		}
+		return title, fmt.Sprintf("%s|%04d|%s", pageSortKey(book.Pages), book.RecipeNumber, titleKey)
+	}
+
+	if len(r.Categories) == 0 {
+		return epubOtherChapter, titleKey
+	}
+	return r.Categories[0], titleKey
+}
+
+func sourceTitle(link string) string {
+	if name := sourceName(link); strings.Contains(link, "://") {
+		return strings.TrimPrefix(name, "www.")
+	}
+	return link
+}
+
+// pageSortKey orders numbered pages numerically, after any roman numeral (or otherwise named) pages.
+func pageSortKey(pages Pages) string {
+	if len(pages) == 0 || len(pages[0]) == 0 {
+		return "~"
+	}
+	first := pages[0][0]
+	if n, err := strconv.Atoi(first); err == nil {
+		return fmt.Sprintf("1%08d", n)
+	}
+	return "0" + first
+}
+
+// epubIdentifier derives a stable UUID URN from the recipes in the book.
+func epubIdentifier(recipes []*Recipe) string {
+	h := sha256.New()
+	for _, r := range recipes {
+		fmt.Fprintf(h, "%s\x00%s\x00", r.ID, r.Title)
+	}
+	sum := h.Sum(nil)
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,166 @@
+package mela_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func TestWriteEPUB(t *testing.T) {
+	recipes, err := mela.Open("fixtures/a+b.melarecipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := mela.Open("fixtures/c.melarecipe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipes = append(recipes, c...)
+
+	var buf bytes.Buffer
+	opts := mela.EPUBOptions{
+		Title:    "Family <favourites>",
+		Author:   "The Family",
+		GroupBy:  mela.EPUBByCategory,
+		Modified: time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC),
+	}
+	if err := mela.WriteEPUB(&buf, recipes, opts); err != nil {
+		t.Fatalf("Unable to write EPUB: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first := zr.File[0]; first.Name != "mimetype" || first.Method != zip.Store {
+		t.Errorf("The first file must be an uncompressed mimetype, got %s (method %d)", first.Name, first.Method)
+	}
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(data)
+
+		if strings.HasSuffix(f.Name, ".xhtml") || strings.HasSuffix(f.Name, ".opf") || strings.HasSuffix(f.Name, ".xml") {
+			if !strings.HasPrefix(string(data), "<?xml ") {
+				t.Errorf("%s doesn't start with an XML declaration", f.Name)
+			}
+			if err := wellFormed(data); err != nil {
+				t.Errorf("%s isn't well-formed XML: %v", f.Name, err)
+			}
+		}
+	}
+
+	for _, name := range []string{"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/cover.xhtml",
+		"OEBPS/images/cover.jpg", "OEBPS/chapter-1.xhtml", "OEBPS/chapter-2.xhtml", "OEBPS/chapter-3.xhtml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("EPUB is missing %s", name)
+		}
+	}
+
+	opf := files["OEBPS/content.opf"]
+	for _, want := range []string{
+		"<dc:title>Family &lt;favourites&gt;</dc:title>",
+		"<dc:creator>The Family</dc:creator>",
+		"<dc:language>en</dc:language>",
+		`<meta property="dcterms:modified">2024-05-01T12:00:00Z</meta>`,
+		`properties="cover-image"`,
+		`<dc:identifier id="book-id">urn:uuid:`,
+	} {
+		if !strings.Contains(opf, want) {
+			t.Errorf("content.opf doesn't contain %q:\n%s", want, opf)
+		}
+	}
+
+	nav := files["OEBPS/nav.xhtml"]
+	for _, want := range []string{
+		`<li><a href="chapter-1.xhtml">a</a>`,
+		`<li><a href="chapter-1.xhtml#recipe-1">A title</a></li>`,
+		`<li><a href="chapter-3.xhtml#recipe-3">C title</a></li>`,
+	} {
+		if !strings.Contains(nav, want) {
+			t.Errorf("nav.xhtml doesn't contain %q:\n%s", want, nav)
+		}
+	}
+
+	chapter := files["OEBPS/chapter-1.xhtml"]
+	for _, want := range []string{`<article class="recipe" id="recipe-1">`, `<img src="images/recipe-1-1.jpg" alt=""/>`, "<li>A ingredients</li>"} {
+		if !strings.Contains(chapter, want) {
+			t.Errorf("chapter-1.xhtml doesn't contain %q:\n%s", want, chapter)
+		}
+	}
+}
+
+func TestWriteEPUB_BySource(t *testing.T) {
+	bookRecipe := func(title string, pages string) *mela.Recipe {
+		r := &mela.Recipe{Title: title, Link: "Fresh & Easy"}
+		if err := r.SetBook("0714863602", mela.MustParsePages(pages), 0); err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	recipes := []*mela.Recipe{
+		bookRecipe("Later", "102"),
+		{Title: "Web", Link: "https://www.example.com/web"},
+		bookRecipe("Earlier", "9"),
+		{Title: "Unknown"},
+	}
+
+	var buf bytes.Buffer
+	if err := mela.WriteEPUB(&buf, recipes, mela.EPUBOptions{GroupBy: mela.EPUBBySource}); err != nil {
+		t.Fatalf("Unable to write EPUB: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nav, err := zr.Open("OEBPS/nav.xhtml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(nav)
+
+	var titles []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, `">`); strings.HasPrefix(line, "<li><a") && i > 0 {
+			titles = append(titles, strings.TrimSuffix(strings.TrimSuffix(line[i+2:], "</li>"), "</a>"))
+		}
+	}
+	want := []string{"example.com", "Web", "Fresh &amp; Easy", "Earlier", "Later", "Other recipes", "Unknown"}
+	if strings.Join(titles, "|") != strings.Join(want, "|") {
+		t.Errorf("Incorrect contents:\nwant = %v\ngot  = %v", want, titles)
+	}
+
+	if _, err := zr.Open("OEBPS/cover.xhtml"); err == nil {
+		t.Error("Expected no cover when there are no images")
+	}
+}
+
+func wellFormed(data []byte) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = true
+	dec.Entity = xml.HTMLEntity
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
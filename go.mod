@@ -7,7 +7,9 @@ toolchain go1.22.3
 require (
 	github.com/gen2brain/jpegli v0.2.2
 	golang.org/x/image v0.0.0-20211028202545-6944b10bf410
-	golang.org/x/text v0.3.6
+	golang.org/x/net v0.25.0
+	golang.org/x/text v0.15.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require github.com/tetratelabs/wazero v1.7.0 // indirect
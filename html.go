@@ -0,0 +1,237 @@
+package mela
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+var melaLink = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+var melaBold = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+var melaItalic = regexp.MustCompile(`\*([^*]+)\*|\b_([^_]+)_\b`)
+
+// HTMLRenderer renders recipes as self-contained HTML pages, with their images and styles embedded.
+type HTMLRenderer struct {
+	templates *template.Template
+}
+
+// NewHTMLRenderer creates a renderer using the built-in templates, replacing any of them with the templates of the
+// same name defined by the *.tmpl files in overrides (which may be nil). The templates are "recipe.html" (given an
+// HTMLRecipePage), "index.html" (given an HTMLIndexPage) and "style.css", which both pages embed.
+func NewHTMLRenderer(overrides fs.FS) (*HTMLRenderer, error) {
+	t, err := template.New("").Funcs(htmlFuncs).ParseFS(defaultTemplates, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	if overrides != nil {
+		matches, err := fs.Glob(overrides, "*.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) > 0 {
+			if t, err = t.ParseFS(overrides, matches...); err != nil {
+				return nil, fmt.Errorf("invalid template: %w", err)
+			}
+		}
+	}
+
+	return &HTMLRenderer{templates: t}, nil
+}
+
+var htmlFuncs = template.FuncMap{
+	"join":       strings.Join,
+	"sections":   func(ss SectionedSequence) []Section { return ss.Sections() },
+	"markdown":   melaMarkdown,
+	"paragraphs": melaParagraphs,
+	// Data URIs are considered unsafe by html/template, but these are known to be images
+	"imageURL": func(img B64Image) template.URL { return template.URL(img.DataURI()) },
+}
+
+// HTMLRecipePage is the data given to the "recipe.html" template.
+type HTMLRecipePage struct {
+	*Recipe
+	// Index is the link back to the bundle's index page, if there is one
+	Index string
+}
+
+// HTMLSource is where a recipe comes from, with a URL if it's a website.
+type HTMLSource struct {
+	Name string
+	URL  string
+}
+
+// Source describes the recipe's Link as a website or a book name, or is nil when there isn't one.
+func (p HTMLRecipePage) Source() *HTMLSource {
+	if p.Link == "" {
+		return nil
+	}
+	if u, err := url.Parse(p.Link); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+		return &HTMLSource{Name: strings.TrimPrefix(u.Host, "www."), URL: p.Link}
+	}
+	return &HTMLSource{Name: p.Link}
+}
+
+// HTMLDetail is a labelled piece of information about a recipe, eg. its cooking time.
+type HTMLDetail struct {
+	Label string
+	Value string
+}
+
+// Details lists the recipe's yield, times and book reference, where it has them.
+func (p HTMLRecipePage) Details() []HTMLDetail {
+	var details []HTMLDetail
+	for _, d := range []HTMLDetail{
+		{"Yield", string(p.Yield)},
+		{"Prep time", string(p.PrepTime)},
+		{"Cook time", string(p.CookTime)},
+		{"Total time", string(p.TotalTime)},
+	} {
+		if d.Value != "" {
+			details = append(details, d)
+		}
+	}
+
+	if book := p.Book(); book != nil {
+		value := "ISBN " + book.ISBN13
+		if len(book.Pages) > 0 {
+			value += ", p." + book.Pages.String()
+		}
+		details = append(details, HTMLDetail{"Book", value})
+	}
+
+	return details
+}
+
+// HTMLIndexPage is the data given to the "index.html" template.
+type HTMLIndexPage struct {
+	Title   string
+	Recipes []HTMLIndexEntry
+}
+
+// HTMLIndexEntry is a recipe listed on the index page.
+type HTMLIndexEntry struct {
+	*Recipe
+	// Page is the link to the recipe's own page
+	Page string
+}
+
+// RenderRecipe writes the recipe as a standalone HTML page.
+func (h *HTMLRenderer) RenderRecipe(w io.Writer, r *Recipe) error {
+	return h.templates.ExecuteTemplate(w, "recipe.html", HTMLRecipePage{Recipe: r})
+}
+
+// RenderBundle writes an index.html page listing the given recipes into dir, with a page for each recipe alongside
+// it. It returns the path of the index page.
+func (h *HTMLRenderer) RenderBundle(dir, title string, recipes []*Recipe) (string, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return "", fmt.Errorf("output directory '%s' does not exist", dir)
+	}
+
+	index := HTMLIndexPage{Title: title}
+	used := make(map[string]bool)
+	for _, r := range recipes {
+		page := htmlPageName(r, used)
+		index.Recipes = append(index.Recipes, HTMLIndexEntry{Recipe: r, Page: page})
+
+		if err := h.renderFile(filepath.Join(dir, page), "recipe.html", HTMLRecipePage{Recipe: r, Index: "index.html"}); err != nil {
+			return "", fmt.Errorf("unable to render '%s': %w", r.Title, err)
+		}
+	}
+
+	indexPath := filepath.Join(dir, "index.html")
+	if err := h.renderFile(indexPath, "index.html", index); err != nil {
+		return "", fmt.Errorf("unable to render index: %w", err)
+	}
+	return indexPath, nil
+}
+
+func (h *HTMLRenderer) renderFile(filename, name string, data any) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := h.templates.ExecuteTemplate(f, name, data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// htmlPageName picks a unique filename for a recipe's page.
+func htmlPageName(r *Recipe, used map[string]bool) string {
+	base := stringToFilename(filepath.Base(r.Filename))
+	if base == "" {
+		base = stringToFilename(r.Title)
+	}
+	if base == "" || base == "index" {
+		base = "recipe"
+	}
+
+	name := base + ".html"
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s-%d.html", base, i)
+	}
+	used[name] = true
+	return name
+}
+
+// melaMarkdown renders the inline Markdown Mela supports (bold, italic and links) as HTML, escaping everything else.
+func melaMarkdown(text string) template.HTML {
+	var b strings.Builder
+	last := 0
+	for _, m := range melaLink.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(melaEmphasis(text[last:m[0]]))
+
+		label, href := text[m[2]:m[3]], text[m[4]:m[5]]
+		if safeLink(href) {
+			fmt.Fprintf(&b, `<a href="%s">%s</a>`, template.HTMLEscapeString(href), melaEmphasis(label))
+		} else {
+			b.WriteString(melaEmphasis(label))
+		}
+		last = m[1]
+	}
+	b.WriteString(melaEmphasis(text[last:]))
+
+	return template.HTML(b.String())
+}
+
+func melaEmphasis(text string) string {
+	text = template.HTMLEscapeString(text)
+	text = melaBold.ReplaceAllString(text, "<strong>$1$2</strong>")
+	return melaItalic.ReplaceAllString(text, "<em>$1$2</em>")
+}
+
+func safeLink(href string) bool {
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// melaParagraphs renders each line of text as a paragraph of Mela Markdown.
+func melaParagraphs(text string) template.HTML {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fmt.Fprintf(&b, "<p>%s</p>", melaMarkdown(line))
+		}
+	}
+	return template.HTML(b.String())
+}
@@ -0,0 +1,101 @@
+package mela_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func TestHTMLRenderer_RenderRecipe(t *testing.T) {
+	renderer, err := mela.NewHTMLRenderer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &mela.Recipe{
+		Title:        "Toast <3",
+		Link:         "https://www.example.com/toast",
+		Categories:   []string{"Breakfast", "Quick"},
+		Text:         "The **best** toast, from [my blog](https://example.com/blog).",
+		Ingredients:  "1 slice bread\n# Topping\n*Salted* butter",
+		Instructions: "Toast the bread.\nSpread with butter.",
+		Notes:        "Try [this](javascript:void) <script>alert(1)</script>",
+		Yield:        "1",
+		TotalTime:    "5 mins",
+		Images:       []mela.B64Image{[]byte("\xff\xd8\xff\xe0not a real jpeg")},
+	}
+	if err := r.SetBook("0714863602", mela.MustParsePages("42"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.RenderRecipe(&buf, r); err != nil {
+		t.Fatalf("Unable to render recipe: %v", err)
+	}
+	html := buf.String()
+
+	for _, want := range []string{
+		"<title>Toast &lt;3</title>",
+		`<p class="source"><a href="https://www.example.com/toast">example.com</a></p>`,
+		`<p class="categories">Breakfast, Quick</p>`,
+		"<div><dt>Total time</dt><dd>5 mins</dd></div>",
+		"<div><dt>Book</dt><dd>ISBN 9780714863603, p.42</dd></div>",
+		`<img src="data:image/jpeg;base64,`,
+		`The <strong>best</strong> toast, from <a href="https://example.com/blog">my blog</a>.`,
+		"<h3>Topping</h3>\n<ul>\n<li><em>Salted</em> butter</li>",
+		"<ol>\n<li>Toast the bread.</li>\n<li>Spread with butter.</li>\n</ol>",
+		"<p>Try this &lt;script&gt;alert(1)&lt;/script&gt;</p>",
+		"@media print",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Rendered HTML doesn't contain %q:\n%s", want, html)
+		}
+	}
+}
+
+func TestHTMLRenderer_RenderBundle(t *testing.T) {
+	overrides := fstest.MapFS{
+		"style.tmpl": &fstest.MapFile{Data: []byte(`{{define "style.css"}}body { color: teal; }{{end}}`)},
+	}
+	renderer, err := mela.NewHTMLRenderer(overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recipes, err := mela.Open("fixtures/a+b.melarecipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipes = append(recipes, &mela.Recipe{Title: "B title"})
+
+	dir := t.TempDir()
+	indexPath, err := renderer.RenderBundle(dir, "Our recipes", recipes)
+	if err != nil {
+		t.Fatalf("Unable to render bundle: %v", err)
+	}
+
+	index, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<h1>Our recipes</h1>", `<a href="b-title.html">`, `<a href="a-title.html">`, `<a href="b-title-2.html">`, "body { color: teal; }"} {
+		if !strings.Contains(string(index), want) {
+			t.Errorf("Index doesn't contain %q:\n%s", want, index)
+		}
+	}
+
+	page, err := os.ReadFile(filepath.Join(dir, "a-title.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<h1>A title</h1>", `<a href="index.html">`} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("Recipe page doesn't contain %q:\n%s", want, page)
+		}
+	}
+}
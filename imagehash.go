@@ -0,0 +1,282 @@
+package mela
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// ImageHash is a 64-bit perceptual hash of an image. Unlike a cryptographic hash, similar looking images (eg. the same
+// photo at different sizes or compression levels) have hashes that differ in only a few bits.
+type ImageHash uint64
+
+// Distance is the number of bits that differ between two hashes: 0 for (near) identical images, up to 64.
+func (h ImageHash) Distance(other ImageHash) int {
+	return bits.OnesCount64(uint64(h ^ other))
+}
+
+func (h ImageHash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// DefaultImageDistance is the largest Distance between the PerceptualHash of two images for them to be counted as the
+// same image.
+const DefaultImageDistance = 8
+
+// AverageHash (aHash) is the quickest hash: which parts of an 8×8 grayscale version of the image are brighter than its
+// average.
+func (i B64Image) AverageHash() (ImageHash, error) {
+	grid, err := i.grayscaleGrid(8, 8)
+	if err != nil {
+		return 0, err
+	}
+
+	mean := 0.0
+	for _, v := range grid {
+		mean += v
+	}
+	mean /= float64(len(grid))
+
+	return hashBits(grid, func(_ int, v float64) bool { return v > mean }), nil
+}
+
+// DifferenceHash (dHash) tracks gradients: which parts of a 9×8 grayscale version of the image are brighter than the
+// part to their right.
+func (i B64Image) DifferenceHash() (ImageHash, error) {
+	grid, err := i.grayscaleGrid(9, 8)
+	if err != nil {
+		return 0, err
+	}
+
+	var h ImageHash
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			h <<= 1
+			if grid[y*9+x] > grid[y*9+x+1] {
+				h |= 1
+			}
+		}
+	}
+	return h, nil
+}
+
+// PerceptualHash (pHash) is the most robust hash, looking at the image's overall structure: which of the lowest
+// frequencies of a 32×32 grayscale version's discrete cosine transform are above their median.
+func (i B64Image) PerceptualHash() (ImageHash, error) {
+	const size, keep = 32, 8
+
+	grid, err := i.grayscaleGrid(size, size)
+	if err != nil {
+		return 0, err
+	}
+
+	// A 2D DCT-II, only as far as the frequencies kept
+	cosines := make([][]float64, keep)
+	for u := range cosines {
+		cosines[u] = make([]float64, size)
+		for x := range cosines[u] {
+			cosines[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * size))
+		}
+	}
+	rows := make([]float64, size*keep)
+	for y := 0; y < size; y++ {
+		for u := 0; u < keep; u++ {
+			for x := 0; x < size; x++ {
+				rows[y*keep+u] += grid[y*size+x] * cosines[u][x]
+			}
+		}
+	}
+	dct := make([]float64, keep*keep)
+	for v := 0; v < keep; v++ {
+		for u := 0; u < keep; u++ {
+			for y := 0; y < size; y++ {
+				dct[v*keep+u] += rows[y*keep+u] * cosines[v][y]
+			}
+		}
+	}
+
+	// The first coefficient is the image's average brightness, which would skew the median
+	sorted := append([]float64{}, dct[1:]...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	return hashBits(dct, func(_ int, v float64) bool { return v > median }), nil
+}
+
+func hashBits(values []float64, set func(int, float64) bool) ImageHash {
+	var h ImageHash
+	for n, v := range values {
+		h <<= 1
+		if set(n, v) {
+			h |= 1
+		}
+	}
+	return h
+}
+
+// grayscaleGrid shrinks the image to the given size, in grayscale, averaging the brightness of every pixel that falls in
+// each cell (so it isn't affected by the image's original size).
+func (i B64Image) grayscaleGrid(width, height int) ([]float64, error) {
+	img, _, err := image.Decode(bytes.NewReader(i))
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	if b.Empty() {
+		return nil, fmt.Errorf("image has no pixels")
+	}
+
+	sums := make([]float64, width*height)
+	counts := make([]int, width*height)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := (y - b.Min.Y) * height / b.Dy() * width
+		for x := b.Min.X; x < b.Max.X; x++ {
+			cell := row + (x-b.Min.X)*width/b.Dx()
+			sums[cell] += luminance(img, x, y)
+			counts[cell]++
+		}
+	}
+
+	// Images smaller than the grid have some empty cells, which take the pixel that covers them
+	for cell := range sums {
+		if counts[cell] > 0 {
+			sums[cell] /= float64(counts[cell])
+			continue
+		}
+		x := b.Min.X + (cell%width)*b.Dx()/width
+		y := b.Min.Y + (cell/width)*b.Dy()/height
+		sums[cell] = luminance(img, x, y)
+	}
+	return sums, nil
+}
+
+func luminance(img image.Image, x, y int) float64 {
+	switch i := img.(type) {
+	case *image.YCbCr:
+		return float64(i.Y[i.YOffset(x, y)]) * 0x101
+	case *image.Gray:
+		return float64(i.GrayAt(x, y).Y) * 0x101
+	}
+	r, g, b, _ := img.At(x, y).RGBA()
+	return 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+}
+
+// GroupSimilarImages finds the images that are (near) identical to each other, by their PerceptualHash, returning
+// groups of their positions. Images that are only like themselves, or that can't be decoded, aren't in any group.
+func GroupSimilarImages(images []B64Image, maxDistance int) [][]int {
+	var refs []ImageRef
+	for n := range images {
+		refs = append(refs, ImageRef{Image: n})
+	}
+
+	var groups [][]int
+	for _, group := range groupSimilarImages(refs, func(ref ImageRef) B64Image { return images[ref.Image] }, maxDistance) {
+		var positions []int
+		for _, ref := range group {
+			positions = append(positions, ref.Image)
+		}
+		groups = append(groups, positions)
+	}
+	return groups
+}
+
+// ImageRef is an image within a library: the position of its recipe, and its position in that recipe's images.
+type ImageRef struct {
+	Recipe int
+	Image  int
+}
+
+// FindDuplicateImages finds the images across all the recipes that are (near) identical to each other, by their
+// PerceptualHash, returning groups of them.
+func FindDuplicateImages(recipes []*Recipe, maxDistance int) [][]ImageRef {
+	var refs []ImageRef
+	for n, r := range recipes {
+		for m := range r.Images {
+			refs = append(refs, ImageRef{Recipe: n, Image: m})
+		}
+	}
+	return groupSimilarImages(refs, func(ref ImageRef) B64Image { return recipes[ref.Recipe].Images[ref.Image] }, maxDistance)
+}
+
+func groupSimilarImages(refs []ImageRef, lookup func(ImageRef) B64Image, maxDistance int) [][]ImageRef {
+	var hashed []ImageRef
+	var hashes []ImageHash
+	for _, ref := range refs {
+		h, err := lookup(ref).PerceptualHash()
+		if err != nil {
+			continue
+		}
+		hashed = append(hashed, ref)
+		hashes = append(hashes, h)
+	}
+
+	// Each image joins the group of the first earlier image it's like
+	group := make([]int, len(hashed))
+	for a := range hashed {
+		group[a] = a
+		for b := 0; b < a; b++ {
+			if hashes[a].Distance(hashes[b]) <= maxDistance {
+				group[a] = group[b]
+				break
+			}
+		}
+	}
+
+	members := make(map[int][]ImageRef)
+	var order []int
+	for n, g := range group {
+		if _, ok := members[g]; !ok {
+			order = append(order, g)
+		}
+		members[g] = append(members[g], hashed[n])
+	}
+
+	var groups [][]ImageRef
+	for _, g := range order {
+		if len(members[g]) > 1 {
+			groups = append(groups, members[g])
+		}
+	}
+	return groups
+}
+
+// removeDuplicateImages leaves out images that are near identical to another of the recipe's images, keeping the copy
+// with the highest resolution in the place of the first.
+func removeDuplicateImages(r *Recipe) {
+	groups := GroupSimilarImages(r.Images, DefaultImageDistance)
+	if len(groups) == 0 {
+		return
+	}
+
+	drop := make(map[int]bool)
+	for _, group := range groups {
+		best := group[0]
+		for _, n := range group[1:] {
+			if imagePixels(r.Images[n]) > imagePixels(r.Images[best]) {
+				best = n
+			}
+			drop[n] = true
+		}
+		r.Images[group[0]] = r.Images[best]
+	}
+
+	images := make([]B64Image, 0, len(r.Images)-len(drop))
+	for n, img := range r.Images {
+		if !drop[n] {
+			images = append(images, img)
+		}
+	}
+	r.standardizationsMade = append(r.standardizationsMade, fmt.Sprintf("removed %d duplicate images", len(drop)))
+	r.Images = images
+}
+
+func imagePixels(img B64Image) int {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(img))
+	if err != nil {
+		return 0
+	}
+	return cfg.Width * cfg.Height
+}
@@ -0,0 +1,151 @@
+package mela_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"testing"
+
+	. "github.com/jphastings/mela-recipes"
+)
+
+// testPhoto draws a scene that looks the same at any size: a gradient under overlapping rectangles, placed by the seed.
+func testPhoto(t *testing.T, seed int64, width, height int, asJPEG bool) B64Image {
+	t.Helper()
+	type rect struct{ x0, y0, x1, y1, v float64 }
+	rnd := rand.New(rand.NewSource(seed))
+	var rects []rect
+	for n := 0; n < 12; n++ {
+		x, y := rnd.Float64(), rnd.Float64()
+		rects = append(rects, rect{x, y, x + 0.1 + rnd.Float64()*0.4, y + 0.1 + rnd.Float64()*0.4, rnd.Float64()})
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			fx, fy := float64(x)/float64(width), float64(y)/float64(height)
+			v := (fx + fy) / 2
+			for _, r := range rects {
+				if fx >= r.x0 && fx < r.x1 && fy >= r.y0 && fy < r.y1 {
+					v = r.v
+				}
+			}
+			img.Set(x, y, color.RGBA{R: uint8(v * 255), G: uint8(v * 200), B: uint8((1 - v) * 150), A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if asJPEG {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 60})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestB64Image_Hashes(t *testing.T) {
+	large := testPhoto(t, 0, 640, 480, false)
+	small := testPhoto(t, 0, 160, 120, true)
+	other := testPhoto(t, 1, 640, 480, false)
+
+	hashes := map[string]func(B64Image) (ImageHash, error){
+		"aHash": B64Image.AverageHash,
+		"dHash": B64Image.DifferenceHash,
+		"pHash": B64Image.PerceptualHash,
+	}
+	for name, hash := range hashes {
+		t.Run(name, func(t *testing.T) {
+			hLarge, err := hash(large)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hSmall, err := hash(small)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hOther, err := hash(other)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if d := hLarge.Distance(hSmall); d > DefaultImageDistance {
+				t.Errorf("resized copies are %d bits apart (%s, %s)", d, hLarge, hSmall)
+			}
+			if d := hLarge.Distance(hOther); d <= DefaultImageDistance {
+				t.Errorf("different images are only %d bits apart (%s, %s)", d, hLarge, hOther)
+			}
+		})
+	}
+
+	if _, err := B64Image("not an image").PerceptualHash(); err == nil {
+		t.Error("expected an error hashing something that isn't an image")
+	}
+}
+
+func TestGroupSimilarImages(t *testing.T) {
+	images := []B64Image{
+		testPhoto(t, 0, 160, 120, true),
+		testPhoto(t, 1, 320, 240, false),
+		B64Image("not an image"),
+		testPhoto(t, 0, 640, 480, false),
+	}
+
+	groups := GroupSimilarImages(images, DefaultImageDistance)
+	if len(groups) != 1 || len(groups[0]) != 2 || groups[0][0] != 0 || groups[0][1] != 3 {
+		t.Errorf("expected images 0 and 3 to be grouped, got %v", groups)
+	}
+}
+
+func TestFindDuplicateImages(t *testing.T) {
+	recipes := []*Recipe{
+		{Images: []B64Image{testPhoto(t, 1, 320, 240, false)}},
+		{Images: []B64Image{testPhoto(t, 0, 320, 240, true), testPhoto(t, 1, 200, 150, true)}},
+	}
+
+	dupes := FindDuplicateImages(recipes, DefaultImageDistance)
+	want := []ImageRef{{Recipe: 0, Image: 0}, {Recipe: 1, Image: 1}}
+	if len(dupes) != 1 || len(dupes[0]) != 2 || dupes[0][0] != want[0] || dupes[0][1] != want[1] {
+		t.Errorf("expected %v to be duplicates, got %v", want, dupes)
+	}
+}
+
+func TestRawRecipe_StandardizeDuplicateImages(t *testing.T) {
+	r := &Recipe{
+		Title: "Duplicate photos",
+		Images: []B64Image{
+			testPhoto(t, 0, 160, 120, true),
+			testPhoto(t, 1, 300, 200, false),
+			testPhoto(t, 0, 800, 600, false),
+		},
+	}
+	// What the largest copy becomes once optimized
+	want, err := r.Images[2].Optimize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Standardize(false); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Images) != 2 {
+		t.Fatalf("expected 2 images, got %d", len(r.Images))
+	}
+	if !bytes.Equal(r.Images[0], want) {
+		t.Error("expected the highest resolution copy to be kept, in the place of the first")
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(r.Images[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 300 || cfg.Height != 200 {
+		t.Errorf("expected the different image to be kept, got one of %dx%d", cfg.Width, cfg.Height)
+	}
+}
@@ -2,9 +2,11 @@ package mela
 
 import (
 	"bytes"
+	"encoding/base64"
 	"image"
 	_ "image/jpeg"
 	_ "image/png"
+	"net/http"
 
 	_ "golang.org/x/image/webp"
 
@@ -44,6 +46,11 @@ func (i B64Image) OptimizeWithConfig(maxWidth, maxHeight int) (B64Image, error)
 	return buf.Bytes(), nil
 }
 
+// DataURI encodes the image as a data URI, eg. for embedding in HTML.
+func (i B64Image) DataURI() string {
+	return "data:" + http.DetectContentType(i) + ";base64," + base64.StdEncoding.EncodeToString(i)
+}
+
 func resizeImage(src image.Image, maxWidth, maxHeight int) (image.Image, bool) {
 	newWidth, newHeight, needsResize := resizeAspectRatio(src.Bounds().Dx(), src.Bounds().Dy(), maxWidth, maxHeight)
 	if !needsResize {
@@ -0,0 +1,259 @@
+package mela
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Ingredient is a single line of a recipe's ingredients list, broken into its parts.
+type Ingredient struct {
+	// Line is the ingredient line as written
+	Line string
+	// Quantity is zero when the line has no amount, eg. "Salt and pepper"
+	Quantity    float64
+	MaxQuantity float64
+	// Unit is the canonical name of the unit of measure, if one is recognised (eg. "g", "tbsp", "clove")
+	Unit string
+	Name string
+	// Note holds any preparation details, eg. "finely chopped"
+	Note string
+}
+
+// Dimension groups units that can be converted between each other.
+type Dimension string
+
+const (
+	DimensionMass   Dimension = "mass"
+	DimensionVolume Dimension = "volume"
+	DimensionCount  Dimension = "count"
+)
+
+// MeasurementUnit describes a unit of measure an ingredient can be given in.
+type MeasurementUnit struct {
+	Name      string
+	Dimension Dimension
+	// Factor converts the unit into the dimension's base unit (grams, millilitres); zero for units that can't be converted
+	Factor  float64
+	aliases []string
+}
+
+var measurementUnits = []MeasurementUnit{
+	{"mg", DimensionMass, 0.001, []string{"milligram", "milligrams", "milligramme", "milligrammes"}},
+	{"g", DimensionMass, 1, []string{"gram", "grams", "gramme", "grammes", "gr", "grm"}},
+	{"kg", DimensionMass, 1000, []string{"kilogram", "kilograms", "kilogramme", "kilogrammes", "kilo", "kilos", "kgs"}},
+	{"oz", DimensionMass, 28.3495, []string{"ounce", "ounces"}},
+	{"lb", DimensionMass, 453.592, []string{"lbs", "pound", "pounds"}},
+	{"ml", DimensionVolume, 1, []string{"millilitre", "millilitres", "milliliter", "milliliters", "mls"}},
+	{"cl", DimensionVolume, 10, []string{"centilitre", "centilitres", "centiliter", "centiliters"}},
+	{"dl", DimensionVolume, 100, []string{"decilitre", "decilitres", "deciliter", "deciliters"}},
+	{"l", DimensionVolume, 1000, []string{"litre", "litres", "liter", "liters", "ltr"}},
+	{"tsp", DimensionVolume, 5, []string{"teaspoon", "teaspoons", "tsps", "t"}},
+	{"tbsp", DimensionVolume, 15, []string{"tablespoon", "tablespoons", "tbsps", "tbs", "tbl", "T"}},
+	{"fl oz", DimensionVolume, 29.5735, []string{"fluid ounce", "fluid ounces", "fl. oz", "fl. oz."}},
+	{"cup", DimensionVolume, 240, []string{"cups", "c"}},
+	{"pint", DimensionVolume, 568.261, []string{"pints", "pt", "pts"}},
+	{"quart", DimensionVolume, 946.353, []string{"quarts", "qt", "qts"}},
+	{"gallon", DimensionVolume, 3785.41, []string{"gallons", "gal"}},
+	{"pinch", DimensionCount, 0, []string{"pinches"}},
+	{"dash", DimensionCount, 0, []string{"dashes"}},
+	{"handful", DimensionCount, 0, []string{"handfuls"}},
+	{"bunch", DimensionCount, 0, []string{"bunches"}},
+	{"sprig", DimensionCount, 0, []string{"sprigs"}},
+	{"clove", DimensionCount, 0, []string{"cloves"}},
+	{"slice", DimensionCount, 0, []string{"slices"}},
+	{"stick", DimensionCount, 0, []string{"sticks"}},
+	{"can", DimensionCount, 0, []string{"cans"}},
+	{"tin", DimensionCount, 0, []string{"tins"}},
+	{"packet", DimensionCount, 0, []string{"packets", "pack", "packs", "pkg"}},
+	{"jar", DimensionCount, 0, []string{"jars"}},
+	{"knob", DimensionCount, 0, []string{"knobs"}},
+}
+
+var unitsByAlias = func() map[string]MeasurementUnit {
+	m := make(map[string]MeasurementUnit)
+	for _, u := range measurementUnits {
+		m[u.Name] = u
+		for _, a := range u.aliases {
+			m[a] = u
+		}
+	}
+	return m
+}()
+
+// unitAliases are all the ways units can be written, longest first so that "fl oz" is matched before "fl". Single
+// letters (eg. "t", "c") are kept separately in unitLetters, as they need a space after them.
+var unitAliases, unitLetters = func() ([]string, []string) {
+	var as, ls []string
+	for a := range unitsByAlias {
+		if len(a) == 1 {
+			ls = append(ls, a)
+			continue
+		}
+		as = append(as, regexp.QuoteMeta(a))
+	}
+	sort.Strings(ls)
+	sort.Slice(as, func(i, j int) bool {
+		if len(as[i]) != len(as[j]) {
+			return len(as[i]) > len(as[j])
+		}
+		return as[i] < as[j]
+	})
+	return as, ls
+}()
+
+// LookupUnit finds the unit of measure with the given name or abbreviation.
+func LookupUnit(name string) (MeasurementUnit, bool) {
+	if u, ok := unitsByAlias[name]; ok {
+		return u, true
+	}
+	u, ok := unitsByAlias[strings.ToLower(name)]
+	return u, ok
+}
+
+var ingredientBullet = regexp.MustCompile(`^\s*(?:[-*•·]|\d+[.)])\s+`)
+var ingredientQuantity = regexp.MustCompile(`^` + yieldNumber + `(?:\s*(?:-|–|—|to)\s*` + yieldNumber + `)?\s*`)
+var ingredientUnit = regexp.MustCompile(`(?i)^(?:(` + strings.Join(unitAliases, "|") + `)\.?(?:\s+|$|\b)|(` +
+	strings.Join(unitLetters, "|") + `)\.?(?:\s+|$))`)
+var ingredientParenthetical = regexp.MustCompile(`\s*\([^)]*\)\s*`)
+var ingredientOf = regexp.MustCompile(`(?i)^of\s+`)
+var ingredientArticle = regexp.MustCompile(`(?i)^an?\s+`)
+
+// ParseIngredient breaks an ingredient line like "200g plain flour, sifted" into its parts.
+func ParseIngredient(line string) Ingredient {
+	ing := Ingredient{Line: line}
+	rest := ingredientBullet.ReplaceAllString(strings.TrimSpace(line), "")
+
+	if m := ingredientQuantity.FindStringSubmatch(rest); m != nil {
+		if q, err := parseYieldNumber(m[1]); err == nil {
+			ing.Quantity, ing.MaxQuantity = q, q
+			rest = rest[len(m[0]):]
+			if m[2] != "" {
+				if maxQ, err := parseYieldNumber(m[2]); err == nil {
+					ing.MaxQuantity = maxQ
+				}
+			}
+		}
+	}
+
+	// eg. "A pinch of salt", but not "A little oil"
+	if m := ingredientArticle.FindString(rest); m != "" && ing.Quantity == 0 {
+		if ingredientUnit.MatchString(rest[len(m):]) {
+			ing.Quantity, ing.MaxQuantity = 1, 1
+			rest = rest[len(m):]
+		}
+	}
+
+	// eg. "1 (400g) tin tomatoes"
+	if strings.HasPrefix(rest, "(") {
+		if end := strings.Index(rest, ")"); end > 0 {
+			ing.Note = strings.TrimSpace(rest[1:end])
+			rest = strings.TrimSpace(rest[end+1:])
+		}
+	}
+
+	// Without an amount, units are only trusted when followed by "of", eg. "Pinch of salt" but not "Gram flour"
+	if m := ingredientUnit.FindStringSubmatch(rest); m != nil && (ing.Quantity > 0 || ingredientOf.MatchString(rest[len(m[0]):])) {
+		if u, ok := LookupUnit(m[1] + m[2]); ok {
+			ing.Unit = u.Name
+			rest = rest[len(m[0]):]
+		}
+	}
+
+	rest = ingredientOf.ReplaceAllString(strings.TrimSpace(rest), "")
+
+	name, note, _ := strings.Cut(rest, ",")
+	name = strings.TrimSpace(ingredientParenthetical.ReplaceAllString(name, " "))
+	ing.Name = name
+	if note = strings.TrimSpace(note); note != "" {
+		if ing.Note != "" {
+			ing.Note += ", "
+		}
+		ing.Note += note
+	}
+
+	return ing
+}
+
+// ParseIngredients parses every line of an ingredients list, grouped into their sections.
+func (ss SectionedSequence) ParseIngredients() map[string][]Ingredient {
+	ings := make(map[string][]Ingredient)
+	for _, s := range ss.Sections() {
+		for _, line := range s.Lines {
+			ings[s.Heading] = append(ings[s.Heading], ParseIngredient(line))
+		}
+	}
+	return ings
+}
+
+// IngredientList returns every ingredient in the recipe, in order, ignoring sections.
+func (r *Recipe) IngredientList() []Ingredient {
+	var ings []Ingredient
+	for _, s := range r.Ingredients.Sections() {
+		for _, line := range s.Lines {
+			ings = append(ings, ParseIngredient(line))
+		}
+	}
+	return ings
+}
+
+var wordSplitter = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// genericNouns are words that often end an ingredient's name but aren't how a method will refer to it, eg. "basil
+// leaves" is usually "the basil".
+var genericNouns = map[string]bool{
+	"leaf": true, "leave": true, "fillet": true, "stalk": true, "piece": true, "breast": true, "thigh": true,
+	"chunk": true, "floret": true, "wedge": true, "strip": true,
+}
+
+// mentionedIn reports whether the given text refers to the ingredient. See mentionIn.
+func (ing Ingredient) mentionedIn(text string) bool {
+	return ing.mentionIn(text) != nil
+}
+
+var wordFinder = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// mentionIn finds the first place the given text refers to the ingredient by the final word of its name (so "the
+// shallots" is found for "banana shallots"), allowing for simple plurals. It returns the start and end of the word
+// found, or nil if the ingredient isn't mentioned.
+func (ing Ingredient) mentionIn(text string) []int {
+	var words []string
+	for _, w := range wordFinder.FindAllString(strings.ToLower(ing.Name), -1) {
+		words = append(words, singular(w))
+	}
+	if len(words) == 0 {
+		return nil
+	}
+
+	last := words[len(words)-1]
+	alternative := last
+	if genericNouns[last] && len(words) > 1 {
+		alternative = words[len(words)-2]
+	}
+
+	for _, loc := range wordFinder.FindAllStringIndex(text, -1) {
+		w := singular(strings.ToLower(text[loc[0]:loc[1]]))
+		if w == last || w == alternative {
+			return loc
+		}
+	}
+	return nil
+}
+
+// iSingulars are words ending in "i" whose plurals end in "ies", eg. "chillies", unlike most (eg. "cherries").
+var iSingulars = map[string]bool{"chilli": true, "chili": true}
+
+func singular(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies") && iSingulars[word[:len(word)-2]]:
+		return word[:len(word)-2]
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 4 && (strings.HasSuffix(word, "oes") || strings.HasSuffix(word, "ches") || strings.HasSuffix(word, "shes")):
+		return word[:len(word)-2]
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return word[:len(word)-1]
+	default:
+		return word
+	}
+}
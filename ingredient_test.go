@@ -0,0 +1,47 @@
+package mela_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/jphastings/mela-recipes"
+)
+
+func TestParseIngredient(t *testing.T) {
+	type test struct {
+		line string
+		want Ingredient
+	}
+
+	tests := []test{
+		{"200g plain flour, sifted", Ingredient{Quantity: 200, MaxQuantity: 200, Unit: "g", Name: "plain flour", Note: "sifted"}},
+		{"2 tbsp olive oil", Ingredient{Quantity: 2, MaxQuantity: 2, Unit: "tbsp", Name: "olive oil"}},
+		{"1 Tablespoon sugar", Ingredient{Quantity: 1, MaxQuantity: 1, Unit: "tbsp", Name: "sugar"}},
+		{"½ tsp salt", Ingredient{Quantity: 0.5, MaxQuantity: 0.5, Unit: "tsp", Name: "salt"}},
+		{"1 ½ cups milk", Ingredient{Quantity: 1.5, MaxQuantity: 1.5, Unit: "cup", Name: "milk"}},
+		{"3-4 cloves garlic, crushed", Ingredient{Quantity: 3, MaxQuantity: 4, Unit: "clove", Name: "garlic", Note: "crushed"}},
+		{"1 (400g) tin chopped tomatoes", Ingredient{Quantity: 1, MaxQuantity: 1, Unit: "tin", Name: "chopped tomatoes", Note: "400g"}},
+		{"2 large onions (about 300g), finely chopped", Ingredient{Quantity: 2, MaxQuantity: 2, Name: "large onions", Note: "finely chopped"}},
+		{"- 1 lb of ground beef", Ingredient{Quantity: 1, MaxQuantity: 1, Unit: "lb", Name: "ground beef"}},
+		{"Pinch of salt", Ingredient{Unit: "pinch", Name: "salt"}},
+		{"A handful of basil", Ingredient{Quantity: 1, MaxQuantity: 1, Unit: "handful", Name: "basil"}},
+		{"A little oil", Ingredient{Name: "A little oil"}},
+		{"Gram flour", Ingredient{Name: "Gram flour"}},
+		{"Salt and pepper", Ingredient{Name: "Salt and pepper"}},
+		{"1 1/2 cups flour", Ingredient{Quantity: 1.5, MaxQuantity: 1.5, Unit: "cup", Name: "flour"}},
+		{"2½ tbsp butter", Ingredient{Quantity: 2.5, MaxQuantity: 2.5, Unit: "tbsp", Name: "butter"}},
+		{"1 1/2-2 tsp chilli flakes", Ingredient{Quantity: 1.5, MaxQuantity: 2, Unit: "tsp", Name: "chilli flakes"}},
+		{"1 T sugar", Ingredient{Quantity: 1, MaxQuantity: 1, Unit: "tbsp", Name: "sugar"}},
+		{"2 t-bone steaks", Ingredient{Quantity: 2, MaxQuantity: 2, Name: "t-bone steaks"}},
+		{"3 tomatoes", Ingredient{Quantity: 3, MaxQuantity: 3, Name: "tomatoes"}},
+	}
+
+	for _, test := range tests {
+		got := ParseIngredient(test.line)
+		test.want.Line = test.line
+
+		if !reflect.DeepEqual(test.want, got) {
+			t.Errorf("Incorrect ingredient for '%s': want = %#v, got = %#v", test.line, test.want, got)
+		}
+	}
+}
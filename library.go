@@ -0,0 +1,79 @@
+package mela
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// WalkRecipes finds every .melarecipe and .melarecipes file in the tree rooted at root within fsys (eg. a directory on
+// disk with os.DirFS, an embed.FS or a zip.Reader), calling the onRecipe func for each recipe, as it is parsed, with the
+// path of the file it came from. Recipes from a .melarecipes file all have its path, and are told apart by their
+// Filename. Files that can't be parsed are passed to onRecipe as errors, problems walking the tree are returned.
+func WalkRecipes(fsys fs.FS, root string, onRecipe func(path string, r *Recipe, err error)) error {
+	return fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		switch strings.ToLower(path.Ext(p)) {
+		case ".melarecipe", ".melarecipes":
+			err := parseFSFile(fsys, p, func(r *Recipe, err error) { onRecipe(p, r, err) })
+			if err != nil {
+				onRecipe(p, nil, fmt.Errorf("unable to parse '%s': %w", p, err))
+			}
+		}
+		return nil
+	})
+}
+
+// OpenFS is Open for a file within fsys, or a directory, in which case every recipe within it is returned, as found by
+// WalkRecipes. Invalid recipes are silently ignored, use WalkRecipes for greater control.
+func OpenFS(fsys fs.FS, name string) ([]*Recipe, error) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var recipes []*Recipe
+	collect := func(r *Recipe, err error) {
+		if err == nil {
+			recipes = append(recipes, r)
+		}
+	}
+
+	if info.IsDir() {
+		err = WalkRecipes(fsys, name, func(_ string, r *Recipe, err error) { collect(r, err) })
+	} else {
+		err = parseFSFile(fsys, name, collect)
+	}
+	return recipes, err
+}
+
+// parseFSFile parses a .melarecipe or .melarecipes file, telling them apart by their contents.
+func parseFSFile(fsys fs.FS, name string, onRecipe func(*Recipe, error)) error {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(data) > 0 && data[0] == '{':
+		r, err := ParseRecipe(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		r.Filename = withoutExt(path.Base(name))
+		onRecipe(r, nil)
+		return nil
+	case bytes.HasPrefix(data, []byte(ZipFileMagicBytes)):
+		return ParseRecipes(bytes.NewReader(data), int64(len(data)), onRecipe)
+	default:
+		return ErrInvalidMelaFile
+	}
+}
@@ -0,0 +1,198 @@
+package mela_test
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func TestWalkRecipes(t *testing.T) {
+	found := make(map[string][]string)
+	err := mela.WalkRecipes(os.DirFS("fixtures"), ".", func(path string, r *mela.Recipe, err error) {
+		if err != nil {
+			t.Errorf("Unable to parse %s: %v", path, err)
+			return
+		}
+		found[path] = append(found[path], r.ID)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"a.melarecipe":    {"a"},
+		"b.melarecipe":    {"b"},
+		"c.melarecipe":    {"c"},
+		"a+b.melarecipes": {"b", "a"},
+	}
+	for path, ids := range want {
+		if len(found[path]) != len(ids) {
+			t.Errorf("For %s, wanted %d recipes, got %d", path, len(ids), len(found[path]))
+		}
+	}
+}
+
+func TestWalkRecipes_Invalid(t *testing.T) {
+	fsys := fstest.MapFS{
+		"recipes/broken.melarecipe": {Data: []byte("not a recipe")},
+		"recipes/notes.txt":         {Data: []byte("ignored")},
+	}
+
+	var errs int
+	err := mela.WalkRecipes(fsys, ".", func(path string, r *mela.Recipe, err error) {
+		if err == nil || path != "recipes/broken.melarecipe" {
+			t.Errorf("Expected an error for the broken recipe, got %v from %s", err, path)
+		}
+		errs++
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs != 1 {
+		t.Errorf("Expected 1 error, got %d", errs)
+	}
+}
+
+func TestOpenFS(t *testing.T) {
+	recipes, err := mela.OpenFS(os.DirFS("fixtures"), "a+b.melarecipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recipes) != 2 {
+		t.Fatalf("Wanted 2 recipes, got %d", len(recipes))
+	}
+	EnsureRecipe(t, recipes[0], "b")
+	EnsureRecipe(t, recipes[1], "a")
+}
+
+func TestRecipe_SaveFS(t *testing.T) {
+	var recipes []*mela.Recipe
+	for _, fixture := range []string{"a", "b", "c"} {
+		rs, err := mela.OpenFS(os.DirFS("fixtures"), fixture+".melarecipe")
+		if err != nil {
+			t.Fatal(err)
+		}
+		recipes = append(recipes, rs...)
+	}
+
+	fsys := mela.NewMemFS()
+	if err := fsys.MkdirAll("out", 0755); err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, r := range recipes {
+		dest, err := r.SaveFS(fsys, "out")
+		if err != nil {
+			t.Fatalf("Unable to save %s: %v", r.ID, err)
+		}
+		paths = append(paths, dest)
+	}
+
+	saved, err := mela.OpenFS(fsys, "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 3 {
+		t.Fatalf("Wanted 3 saved recipes, got %d (saved to %v)", len(saved), paths)
+	}
+	var ids []string
+	for _, r := range saved {
+		ids = append(ids, r.ID)
+	}
+	sort.Strings(ids)
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Incorrect saved recipes: want = %v, got = %v", want, ids)
+	}
+
+	if _, err := recipes[0].SaveFS(fsys, "missing"); err == nil {
+		t.Error("Expected an error saving to a missing directory")
+	}
+}
+
+func TestNewRecipesBundleFS(t *testing.T) {
+	recipes, err := mela.Open("fixtures/a+b.melarecipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := mela.NewMemFS()
+	bundle, err := mela.NewRecipesBundleFS(fsys, ".", "Both")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range recipes {
+		if err := bundle.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := bundle.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := mela.OpenFS(fsys, "both.melarecipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, recipes) {
+		t.Error("Recipes changed when bundled in memory")
+	}
+
+	if _, err := mela.NewRecipesBundleFS(fsys, ".", "Both"); err == nil {
+		t.Error("Expected an error when the bundle already exists")
+	}
+}
+
+func TestMemFS(t *testing.T) {
+	fsys := mela.NewMemFS()
+	if err := fsys.MkdirAll("a/b", 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a/one.txt", "a/b/two.txt"} {
+		w, err := fsys.OpenFile(name, os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(name)); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := fstest.TestFS(fsys, "a/one.txt", "a/b/two.txt"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMemFS_ExclusiveCreate(t *testing.T) {
+	fsys := mela.NewMemFS()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created := 0
+	for n := 0; n < 20; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w, err := fsys.OpenFile("same.txt", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			created++
+			mu.Unlock()
+			w.Close()
+		}()
+	}
+	wg.Wait()
+
+	if created != 1 {
+		t.Errorf("Expected only one exclusive create to succeed, %d did", created)
+	}
+}
@@ -0,0 +1,349 @@
+package mela
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrNoFrontMatter = errors.New("markdown recipe has no YAML front matter")
+
+// markdownMeta is the YAML front matter of a Markdown recipe, in the order it's written.
+type markdownMeta struct {
+	ID         string        `yaml:"id,omitempty"`
+	Title      string        `yaml:"title,omitempty"`
+	Link       string        `yaml:"link,omitempty"`
+	Book       *markdownBook `yaml:"book,omitempty"`
+	Yield      string        `yaml:"yield,omitempty"`
+	PrepTime   string        `yaml:"prepTime,omitempty"`
+	CookTime   string        `yaml:"cookTime,omitempty"`
+	TotalTime  string        `yaml:"totalTime,omitempty"`
+	Categories []string      `yaml:"categories,omitempty"`
+	Favorite   bool          `yaml:"favorite,omitempty"`
+	WantToCook bool          `yaml:"wantToCook,omitempty"`
+	Date       *time.Time    `yaml:"date,omitempty"`
+}
+
+type markdownBook struct {
+	ISBN   string `yaml:"isbn"`
+	Pages  string `yaml:"pages,omitempty"`
+	Recipe uint   `yaml:"recipe,omitempty"`
+}
+
+// The headings of the sections of a Markdown recipe, in the order they're written.
+const (
+	markdownIngredients  = "Ingredients"
+	markdownInstructions = "Instructions"
+	markdownNutrition    = "Nutrition"
+	markdownNotes        = "Notes"
+)
+
+var markdownImage = regexp.MustCompile(`^!\[[^\]]*\]\((?:<([^>]+)>|([^)\s]+))\)$`)
+var markdownListItem = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s+`)
+
+// MarshalMarkdown encodes the recipe as a Markdown document with YAML front matter, referencing the given image paths
+// in place of the recipe's own images. ParseMarkdown reverses this exactly for recipes in the form Mela itself uses
+// (headings as "# Heading", no blank lines within the ingredients or instructions).
+func (r *Recipe) MarshalMarkdown(imagePaths []string) ([]byte, error) {
+	meta := markdownMeta{
+		ID:         r.ID,
+		Title:      r.Title,
+		Link:       r.Link,
+		Yield:      string(r.Yield),
+		PrepTime:   string(r.PrepTime),
+		CookTime:   string(r.CookTime),
+		TotalTime:  string(r.TotalTime),
+		Categories: r.Categories,
+		Favorite:   r.Favorite,
+		WantToCook: r.WantToCook,
+	}
+
+	// The book reference is more readable than the ID it's stored in, so replaces it when nothing would be lost
+	if book := r.Book(); book != nil {
+		var fromBook Recipe
+		if err := fromBook.SetBook(book.ISBN13, book.Pages, book.RecipeNumber); err == nil && fromBook.ID == r.ID {
+			meta.ID = ""
+			meta.Book = &markdownBook{ISBN: book.ISBN13, Recipe: book.RecipeNumber}
+			if book.Pages != nil {
+				meta.Book.Pages = book.Pages.String()
+			}
+		}
+	}
+
+	if r.Date != 0 {
+		date := r.Date.Time()
+		meta.Date = &date
+	}
+
+	front, err := yaml.Marshal(meta)
+	if err != nil {
+		return nil, fmt.Errorf("unable to write front matter: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(front)
+	buf.WriteString("---\n\n")
+
+	fmt.Fprintf(&buf, "# %s\n", r.Title)
+	for _, p := range imagePaths {
+		// Paths with spaces or brackets need angle brackets to be a valid CommonMark link destination
+		if strings.ContainsAny(p, " ()") {
+			p = "<" + p + ">"
+		}
+		fmt.Fprintf(&buf, "\n![](%s)\n", p)
+	}
+	if r.Text != "" {
+		fmt.Fprintf(&buf, "\n%s\n", r.Text)
+	}
+
+	writeMarkdownList(&buf, markdownIngredients, r.Ingredients, func(int) string { return "-" })
+	writeMarkdownList(&buf, markdownInstructions, r.Instructions, func(n int) string { return fmt.Sprintf("%d.", n) })
+
+	for _, s := range []struct{ heading, text string }{{markdownNutrition, r.Nutrition}, {markdownNotes, r.Notes}} {
+		if s.text != "" {
+			fmt.Fprintf(&buf, "\n## %s\n\n%s\n", s.heading, s.text)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeMarkdownList writes a sequence as a list, with its headings one level below the section's.
+func writeMarkdownList(buf *bytes.Buffer, heading string, ss SectionedSequence, bullet func(int) string) {
+	if ss == "" {
+		return
+	}
+
+	fmt.Fprintf(buf, "\n## %s\n", heading)
+	n := 0
+	inList := false
+	for _, line := range strings.Split(string(ss), "\n") {
+		if m := sectionHeading.FindStringSubmatch(line); m != nil {
+			fmt.Fprintf(buf, "\n### %s\n", m[1])
+			n, inList = 0, false
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if !inList {
+			buf.WriteString("\n")
+			inList = true
+		}
+		n++
+		fmt.Fprintf(buf, "%s %s\n", bullet(n), line)
+	}
+}
+
+// SaveMarkdown writes the recipe as a Markdown file in the given directory, with each of its images alongside it as a
+// file named after the recipe, eg. "pancakes.md", "pancakes-1.jpg". It returns the path of the Markdown file.
+func (r *Recipe) SaveMarkdown(dir string) (string, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return "", fmt.Errorf("output directory '%s' does not exist", dir)
+	}
+
+	name := r.Filename
+	if name == "" {
+		name = stringToFilename(r.Title)
+	}
+	name = filepath.Base(name)
+
+	var imagePaths []string
+	for i, img := range r.Images {
+		imagePath := fmt.Sprintf("%s-%d%s", name, i+1, imageExtension(img))
+		if err := os.WriteFile(filepath.Join(dir, imagePath), img, 0644); err != nil {
+			return "", fmt.Errorf("unable to write image file: %w", err)
+		}
+		imagePaths = append(imagePaths, imagePath)
+	}
+
+	data, err := r.MarshalMarkdown(imagePaths)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal recipe: %w", err)
+	}
+
+	destination := filepath.Join(dir, name+".md")
+	if err := os.WriteFile(destination, data, 0644); err != nil {
+		return "", fmt.Errorf("unable to write recipe file: %w", err)
+	}
+
+	return destination, nil
+}
+
+func imageExtension(img B64Image) string {
+	switch http.DetectContentType(img) {
+	case "image/png":
+		return ".png"
+	case "image/webp":
+		return ".webp"
+	case "image/gif":
+		return ".gif"
+	default:
+		return ".jpg"
+	}
+}
+
+// OpenMarkdown reads a Markdown recipe from disk, loading its images from paths relative to the file.
+func OpenMarkdown(filename string) (*Recipe, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := ParseMarkdown(f, os.DirFS(filepath.Dir(filename)))
+	if err != nil {
+		return nil, err
+	}
+	r.Filename = withoutExt(filepath.Base(filename))
+	return r, nil
+}
+
+// ParseMarkdown decodes a Markdown recipe, as written by MarshalMarkdown, loading any images it references from images.
+// A nil images ignores the image references.
+func ParseMarkdown(r io.Reader, images fs.FS) (*Recipe, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rest, ok := strings.CutPrefix(strings.ReplaceAll(string(data), "\r\n", "\n"), "---\n")
+	if !ok {
+		return nil, ErrNoFrontMatter
+	}
+	front, body, ok := strings.Cut(rest, "\n---\n")
+	if !ok {
+		return nil, ErrNoFrontMatter
+	}
+
+	var meta markdownMeta
+	if err := yaml.Unmarshal([]byte(front), &meta); err != nil {
+		return nil, fmt.Errorf("invalid front matter: %w", err)
+	}
+
+	recipe := &Recipe{
+		ID:         meta.ID,
+		Title:      meta.Title,
+		Link:       meta.Link,
+		Yield:      PeopleCount(meta.Yield),
+		PrepTime:   MaybeDuration(meta.PrepTime),
+		CookTime:   MaybeDuration(meta.CookTime),
+		TotalTime:  MaybeDuration(meta.TotalTime),
+		Categories: meta.Categories,
+		Favorite:   meta.Favorite,
+		WantToCook: meta.WantToCook,
+		Images:     make([]B64Image, 0),
+	}
+	if recipe.Categories == nil {
+		recipe.Categories = make([]string, 0)
+	}
+	if meta.Date != nil {
+		recipe.Date = NewAppleDate(*meta.Date)
+	}
+	if meta.ID == "" && meta.Book != nil {
+		pages, err := ParsePages(meta.Book.Pages)
+		if meta.Book.Pages == "" {
+			pages, err = nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid book pages '%s': %w", meta.Book.Pages, err)
+		}
+		if err := recipe.SetBook(meta.Book.ISBN, pages, meta.Book.Recipe); err != nil {
+			return nil, fmt.Errorf("invalid book reference: %w", err)
+		}
+	}
+
+	sections := markdownSections(body)
+
+	var text []string
+	seenTitle := false
+	for _, line := range sections[""] {
+		if m := markdownImage.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			if images == nil {
+				continue
+			}
+			img, err := fs.ReadFile(images, path.Clean(m[1]+m[2]))
+			if err != nil {
+				return nil, fmt.Errorf("unable to read image: %w", err)
+			}
+			recipe.Images = append(recipe.Images, img)
+			continue
+		}
+		if strings.HasPrefix(line, "# ") && !seenTitle && markdownText(text) == "" {
+			seenTitle = true
+			if recipe.Title == "" {
+				recipe.Title = strings.TrimSpace(line[2:])
+			}
+			continue
+		}
+		text = append(text, line)
+	}
+
+	recipe.Text = markdownText(text)
+	recipe.Ingredients = markdownList(sections[markdownIngredients])
+	recipe.Instructions = markdownList(sections[markdownInstructions])
+	recipe.Nutrition = markdownText(sections[markdownNutrition])
+	recipe.Notes = markdownText(sections[markdownNotes])
+
+	return recipe, nil
+}
+
+// markdownSections splits the body of a Markdown recipe by its known "## " headings. Any other headings are left as
+// part of the section they're in.
+func markdownSections(body string) map[string][]string {
+	sections := make(map[string][]string)
+	heading := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	scanner.Buffer(nil, len(body)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if h, ok := strings.CutPrefix(line, "## "); ok {
+			switch h = strings.TrimSpace(h); h {
+			case markdownIngredients, markdownInstructions, markdownNutrition, markdownNotes:
+				heading = h
+				continue
+			}
+		}
+		sections[heading] = append(sections[heading], line)
+	}
+	return sections
+}
+
+// markdownText joins lines of free text, without the blank lines around them.
+func markdownText(lines []string) string {
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// markdownList turns the lists and "### " headings of a Markdown section back into a SectionedSequence.
+func markdownList(lines []string) SectionedSequence {
+	var out []string
+	for _, line := range lines {
+		if h, ok := strings.CutPrefix(line, "### "); ok {
+			out = append(out, "# "+strings.TrimSpace(h))
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if loc := markdownListItem.FindStringIndex(line); loc != nil {
+			line = line[loc[1]:]
+		}
+		out = append(out, line)
+	}
+	return SectionedSequence(strings.Join(out, "\n"))
+}
@@ -0,0 +1,190 @@
+package mela_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func TestRecipe_MarshalMarkdown(t *testing.T) {
+	r := &mela.Recipe{
+		Title:        "Shallot pasta",
+		Link:         "Fresh & Easy",
+		Text:         "A quick supper.",
+		Ingredients:  "4 banana shallots\n# Pasta\n200g spaghetti",
+		Instructions: "Fry the shallots.\n# Pasta\nBoil the spaghetti.\nToss with the shallots.",
+		Categories:   []string{"pasta"},
+		Yield:        "2",
+		TotalTime:    "30 mins",
+		Notes:        "Add chilli if you like.",
+		Favorite:     true,
+	}
+	if err := r.SetBook("0714863602", mela.MustParsePages("42"), 3); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.MarshalMarkdown([]string{"shallot-pasta-1.jpg"})
+	if err != nil {
+		t.Fatalf("Unable to marshal Markdown: %v", err)
+	}
+
+	want := `---
+title: Shallot pasta
+link: Fresh & Easy
+book:
+    isbn: "9780714863603"
+    pages: "42"
+    recipe: 3
+yield: "2"
+totalTime: 30 mins
+categories:
+    - pasta
+favorite: true
+---
+
+# Shallot pasta
+
+![](shallot-pasta-1.jpg)
+
+A quick supper.
+
+## Ingredients
+
+- 4 banana shallots
+
+### Pasta
+
+- 200g spaghetti
+
+## Instructions
+
+1. Fry the shallots.
+
+### Pasta
+
+1. Boil the spaghetti.
+2. Toss with the shallots.
+
+## Notes
+
+Add chilli if you like.
+`
+	if string(got) != want {
+		t.Errorf("Incorrect Markdown:\nwant = %s\ngot  = %s", want, got)
+	}
+
+	images := fstest.MapFS{"shallot-pasta-1.jpg": &fstest.MapFile{Data: []byte("not really a jpeg")}}
+	parsed, err := mela.ParseMarkdown(bytes.NewReader(got), images)
+	if err != nil {
+		t.Fatalf("Unable to parse Markdown: %v", err)
+	}
+
+	r.Images = []mela.B64Image{[]byte("not really a jpeg")}
+	if !reflect.DeepEqual(r, parsed) {
+		t.Errorf("Recipe changed in round trip:\nwant = %#v\ngot  = %#v", r, parsed)
+	}
+}
+
+func TestRecipe_SaveMarkdown_RoundTrip(t *testing.T) {
+	for _, fixture := range []string{"a", "b", "c"} {
+		t.Run(fixture, func(t *testing.T) {
+			recipes, err := mela.Open(filepath.Join("fixtures", fixture+".melarecipe"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := recipes[0]
+			want.Filename = fixture
+
+			dir := t.TempDir()
+			filename, err := want.SaveMarkdown(dir)
+			if err != nil {
+				t.Fatalf("Unable to save Markdown: %v", err)
+			}
+
+			images, _ := filepath.Glob(filepath.Join(dir, fixture+"-*"))
+			if len(images) != len(want.Images) {
+				t.Errorf("Incorrect number of image files: want = %d, got = %d", len(want.Images), len(images))
+			}
+
+			got, err := mela.OpenMarkdown(filename)
+			if err != nil {
+				t.Fatalf("Unable to open Markdown: %v", err)
+			}
+
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("Recipe changed in round trip: %#v", got)
+			}
+		})
+	}
+}
+
+func TestRecipe_SaveMarkdown_SpacedFilename(t *testing.T) {
+	recipes, err := mela.Open(filepath.Join("fixtures", "a.melarecipe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := recipes[0]
+	want.Filename = "Shallot tarte (tatin)"
+
+	dir := t.TempDir()
+	filename, err := want.SaveMarkdown(dir)
+	if err != nil {
+		t.Fatalf("Unable to save Markdown: %v", err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "![](<Shallot tarte (tatin)-1.png>)") {
+		t.Errorf("Expected the image path to be in angle brackets, got:\n%s", data)
+	}
+
+	got, err := mela.OpenMarkdown(filename)
+	if err != nil {
+		t.Fatalf("Unable to open Markdown: %v", err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Recipe changed in round trip: %#v", got)
+	}
+}
+
+func TestParseMarkdown_Handwritten(t *testing.T) {
+	md := `---
+title: Toast
+---
+
+# Toast
+
+* 1 slice of bread
+* Butter
+
+## Instructions
+
+Some intro text that isn't a step.
+
+1) Toast the bread.
+2) Butter it.
+`
+	got, err := mela.ParseMarkdown(strings.NewReader(md), nil)
+	if err != nil {
+		t.Fatalf("Unable to parse Markdown: %v", err)
+	}
+
+	if got.Text != "* 1 slice of bread\n* Butter" {
+		t.Errorf("Incorrect text: %q", got.Text)
+	}
+	if want := mela.SectionedSequence("Some intro text that isn't a step.\nToast the bread.\nButter it."); got.Instructions != want {
+		t.Errorf("Incorrect instructions: want = %q, got = %q", want, got.Instructions)
+	}
+
+	if _, err := mela.ParseMarkdown(strings.NewReader("# Toast\n"), nil); err != mela.ErrNoFrontMatter {
+		t.Errorf("Expected ErrNoFrontMatter, got %v", err)
+	}
+}
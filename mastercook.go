@@ -0,0 +1,264 @@
+package mela
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var masterCookStart = regexp.MustCompile(`^\s*\*\s*Exported from\s+MasterCook`)
+var masterCookEnd = regexp.MustCompile(`^\s*(?:- ){4,}-?\s*$`)
+var masterCookField = regexp.MustCompile(`^([A-Za-z][A-Za-z .()]*?)\s*:\s*(.*)$`)
+var masterCookColumnsRule = regexp.MustCompile(`^-{8}\s+-{12}\s+-+`)
+var masterCookPrepTime = regexp.MustCompile(`\s*Preparation Time\s*:\s*(\S*)`)
+var masterCookCategorySplitter = regexp.MustCompile(`\s{2,}`)
+var masterCookAmount = regexp.MustCompile(`^[\d ./-]*$`)
+
+type masterCookState int
+
+const (
+	masterCookOutside masterCookState = iota
+	masterCookTitle
+	masterCookHeaders
+	masterCookIngredients
+	masterCookDirections
+	masterCookFooter
+)
+
+// ParseMasterCook splits a MasterCook export (.mxp) text file into its recipes. Any lines that couldn't be understood
+// are returned alongside the recipes.
+func ParseMasterCook(r io.Reader) ([]*Recipe, []UnparsedLine, error) {
+	var recipes []*Recipe
+	var unparsed []UnparsedLine
+
+	var recipe *Recipe
+	var ingredients, directions, paragraph, notes []string
+	state := masterCookOutside
+	lastField := ""
+	lineNum := 0
+
+	report := func(text, reason string) {
+		title := ""
+		if recipe != nil {
+			title = recipe.Title
+		}
+		unparsed = append(unparsed, UnparsedLine{Line: lineNum, Recipe: title, Text: text, Reason: reason})
+	}
+	endParagraph := func() {
+		if len(paragraph) > 0 {
+			directions = append(directions, strings.Join(paragraph, " "))
+			paragraph = nil
+		}
+	}
+	finish := func() {
+		endParagraph()
+		recipe.Ingredients = SectionedSequence(strings.Join(ingredients, "\n"))
+		recipe.Instructions = SectionedSequence(strings.Join(directions, "\n"))
+		recipe.Notes = strings.TrimSpace(strings.Join(notes, "\n"))
+		recipes = append(recipes, recipe)
+		recipe, ingredients, directions, notes = nil, nil, nil, nil
+		state = masterCookOutside
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), " \t\r\x1a")
+		trimmed := strings.TrimSpace(line)
+
+		if masterCookStart.MatchString(line) {
+			if recipe != nil {
+				finish()
+			}
+			recipe = &Recipe{Categories: make([]string, 0), Images: make([]B64Image, 0)}
+			state = masterCookTitle
+			continue
+		}
+
+		switch state {
+		case masterCookOutside:
+			if trimmed != "" {
+				report(line, "outside of any recipe")
+			}
+
+		case masterCookTitle:
+			if trimmed != "" {
+				recipe.Title = trimmed
+				state = masterCookHeaders
+			}
+
+		case masterCookHeaders:
+			switch {
+			case trimmed == "":
+				lastField = ""
+			case masterCookColumnsRule.MatchString(line):
+				state = masterCookIngredients
+			case strings.HasPrefix(trimmed, "Amount") && strings.Contains(trimmed, "Measure"):
+				// The column titles above the rule
+			case line[0] == ' ' && lastField == "Categories":
+				addMasterCookCategories(recipe, trimmed)
+			default:
+				m := masterCookField.FindStringSubmatch(line)
+				if m == nil || !applyMasterCookField(recipe, m[1], m[2]) {
+					report(line, "unknown header")
+					continue
+				}
+				lastField = m[1]
+			}
+
+		case masterCookIngredients:
+			if trimmed == "" {
+				if len(ingredients) > 0 {
+					state = masterCookDirections
+				}
+				continue
+			}
+			ingredients = append(ingredients, masterCookIngredient(line, report))
+
+		case masterCookDirections:
+			if masterCookEnd.MatchString(line) {
+				endParagraph()
+				state = masterCookFooter
+				continue
+			}
+			if trimmed == "" {
+				endParagraph()
+				continue
+			}
+			paragraph = append(paragraph, trimmed)
+
+		case masterCookFooter:
+			if trimmed == "" {
+				continue
+			}
+			m := masterCookField.FindStringSubmatch(line)
+			if m == nil {
+				if lastField == "NOTES" {
+					notes = append(notes, trimmed)
+					continue
+				}
+				report(line, "unknown footer")
+				continue
+			}
+			lastField = strings.TrimSpace(m[1])
+			if !applyMasterCookFooter(recipe, lastField, strings.TrimSpace(m[2]), &notes) {
+				report(line, "unknown footer")
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if recipe != nil {
+		if state != masterCookFooter {
+			report("", "recipe has no end marker")
+		}
+		finish()
+	}
+
+	return recipes, unparsed, nil
+}
+
+func applyMasterCookField(r *Recipe, key, value string) bool {
+	switch strings.TrimSpace(key) {
+	case "Recipe By":
+		r.Link = strings.TrimSpace(value)
+	case "Serving Size":
+		if m := masterCookPrepTime.FindStringSubmatchIndex(value); m != nil {
+			r.PrepTime = masterCookDuration(value[m[2]:m[3]])
+			value = value[:m[0]]
+		}
+		if value = strings.TrimSpace(value); value != "" && value != "0" {
+			r.Yield = PeopleCount(value)
+		}
+	case "Categories":
+		addMasterCookCategories(r, value)
+	default:
+		return false
+	}
+	return true
+}
+
+func addMasterCookCategories(r *Recipe, value string) {
+	for _, c := range masterCookCategorySplitter.Split(strings.TrimSpace(value), -1) {
+		if c != "" {
+			r.Categories = append(r.Categories, c)
+		}
+	}
+}
+
+func applyMasterCookFooter(r *Recipe, key, value string, notes *[]string) bool {
+	switch {
+	case key == "Source":
+		if r.Link == "" {
+			r.Link = value
+		}
+	case key == "Yield":
+		if r.Yield == "" {
+			r.Yield = PeopleCount(value)
+		}
+	case key == "NOTES":
+		*notes = append(*notes, value)
+	case key == "Copyright" || key == "S(Internet address)" || key == "Description":
+		*notes = append(*notes, key+": "+value)
+	case strings.HasPrefix(key, "Per Serving"):
+		r.Nutrition = value
+	case strings.HasPrefix(key, "Nutr. Assoc"):
+		// Links to MasterCook's own nutrition database
+	default:
+		return false
+	}
+	return true
+}
+
+// masterCookDuration turns MasterCook's "H:MM" times into Mela's form.
+func masterCookDuration(hm string) MaybeDuration {
+	h, m, ok := strings.Cut(hm, ":")
+	hours, err1 := strconv.Atoi(h)
+	mins, err2 := strconv.Atoi(m)
+	if !ok || err1 != nil || err2 != nil {
+		return MaybeDuration(hm)
+	}
+	if hours == 0 && mins == 0 {
+		return ""
+	}
+	return FormatDuration(time.Duration(hours)*time.Hour+time.Duration(mins)*time.Minute, DurationStyleLong)
+}
+
+// masterCookIngredient turns MasterCook's fixed-column ingredient layout (amount, measure, then the ingredient
+// and its preparation after " -- ") into an ingredient line.
+func masterCookIngredient(line string, report func(text, reason string)) string {
+	column := func(from, to int) string {
+		if from >= len(line) {
+			return ""
+		}
+		return strings.TrimSpace(line[from:min(to, len(line))])
+	}
+
+	amount, measure, name := column(0, 8), column(10, 24), column(24, len(line))
+	if !masterCookAmount.MatchString(amount) || (len(line) > 8 && line[8] != ' ') {
+		report(line, "ingredient not in columns")
+		return strings.TrimSpace(line)
+	}
+
+	name, prep, _ := strings.Cut(name, " -- ")
+	if prep = strings.TrimSpace(prep); prep != "" {
+		name += ", " + prep
+	}
+
+	// Headings have neither amount nor measure, eg. "-- Frosting --" or "FROSTING:"
+	if amount == "" && measure == "" {
+		if h := strings.Trim(name, "- "); h != name {
+			return "# " + h
+		}
+		if h, ok := strings.CutSuffix(name, ":"); ok && h == strings.ToUpper(h) {
+			return "# " + h
+		}
+	}
+
+	return joinIngredientParts(mixedFraction(amount), measure, name)
+}
@@ -0,0 +1,58 @@
+package mela_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func TestParseMasterCook(t *testing.T) {
+	f, err := os.Open("fixtures/mastercook/cake.mxp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	recipes, unparsed, err := mela.ParseMasterCook(f)
+	if err != nil {
+		t.Fatalf("Unable to parse MasterCook: %v", err)
+	}
+
+	want := []*mela.Recipe{
+		{
+			Title:       "Chocolate Cake",
+			Link:        "Jane Doe",
+			Categories:  []string{"Desserts", "Cakes", "Chocolate"},
+			Yield:       "8",
+			PrepTime:    "1 hr 15 mins",
+			Images:      []mela.B64Image{},
+			Ingredients: "2 cups plain flour, sifted\n1½ teaspoons baking soda\n# Frosting\n4 ounces dark chocolate, melted\na stray line of text",
+			Instructions: "Mix the flour and baking soda. Bake for 45 minutes.\n" +
+				"Melt the chocolate and spread over the cooled cake.",
+			Nutrition: "300 Calories; 12g Fat",
+			Notes:     "Keeps for three days.",
+		},
+		{
+			Title:        "Toast",
+			Categories:   []string{"Breakfast"},
+			Yield:        "1",
+			PrepTime:     "5 mins",
+			Images:       []mela.B64Image{},
+			Ingredients:  "1 slice bread",
+			Instructions: "Toast the bread.",
+		},
+	}
+	if !reflect.DeepEqual(want, recipes) {
+		t.Errorf("Incorrect recipes:\nwant = %#v\ngot  = %#v", want, recipes)
+	}
+
+	wantUnparsed := []mela.UnparsedLine{
+		{Line: 16, Recipe: "Chocolate Cake", Text: "a stray line of text", Reason: "ingredient not in columns"},
+		{Line: 29, Recipe: "Chocolate Cake", Text: "Serving Ideas : with cream", Reason: "unknown footer"},
+	}
+	if !reflect.DeepEqual(wantUnparsed, unparsed) {
+		t.Errorf("Incorrect unparsed lines:\nwant = %#v\ngot  = %#v", wantUnparsed, unparsed)
+	}
+}
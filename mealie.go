@@ -0,0 +1,333 @@
+package mela
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// mealieUUID matches the IDs Mealie gives recipes; other IDs (eg. links) are left for Mealie to replace.
+var mealieUUID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// mealieRecipe is the JSON structure of a recipe in a Mealie export.
+type mealieRecipe struct {
+	ID                 string              `json:"id,omitempty"`
+	Name               string              `json:"name"`
+	Slug               string              `json:"slug"`
+	Description        string              `json:"description"`
+	RecipeYield        string              `json:"recipeYield"`
+	TotalTime          string              `json:"totalTime"`
+	PrepTime           string              `json:"prepTime"`
+	CookTime           string              `json:"cookTime"`
+	PerformTime        string              `json:"performTime"`
+	RecipeCategory     []mealieTag         `json:"recipeCategory"`
+	Tags               []mealieTag         `json:"tags"`
+	Rating             float64             `json:"rating"`
+	OrgURL             string              `json:"orgURL"`
+	DateAdded          string              `json:"dateAdded,omitempty"`
+	RecipeIngredient   []mealieIngredient  `json:"recipeIngredient"`
+	RecipeInstructions []mealieInstruction `json:"recipeInstructions"`
+	Nutrition          map[string]any      `json:"nutrition"`
+	Notes              []mealieNote        `json:"notes"`
+}
+
+type mealieTag struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type mealieNamed struct {
+	Name string `json:"name"`
+}
+
+type mealieIngredient struct {
+	// Title is the heading of the section this ingredient starts
+	Title         string       `json:"title"`
+	Note          string       `json:"note"`
+	Unit          *mealieNamed `json:"unit"`
+	Food          *mealieNamed `json:"food"`
+	Quantity      float64      `json:"quantity"`
+	DisableAmount bool         `json:"disableAmount"`
+	Display       string       `json:"display"`
+	OriginalText  string       `json:"originalText"`
+}
+
+type mealieInstruction struct {
+	// Title is the heading of the section this step starts
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+type mealieNote struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// mealieImageName is the name Mealie gives a recipe's photo, within its images folder.
+const mealieImageName = "original"
+
+// ParseMealieExport parses a Mealie export zip, which holds a recipes/<slug>/<slug>.json file and
+// recipes/<slug>/images/original.* photo for each recipe, into a stream of Recipes, calling the onRecipe func for each,
+// as it is parsed.
+func ParseMealieExport(r io.ReaderAt, size int64, onRecipe func(*Recipe, error)) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	images := make(map[string]*zip.File)
+	var recipeFiles []*zip.File
+	for _, zf := range zr.File {
+		dir, name := path.Split(zf.Name)
+		switch {
+		case path.Base(dir) == "images" && withoutExt(name) == mealieImageName:
+			images[path.Dir(path.Dir(zf.Name))] = zf
+		case path.Ext(name) == ".json" && withoutExt(name) == path.Base(dir):
+			recipeFiles = append(recipeFiles, zf)
+		}
+	}
+
+	for _, zf := range recipeFiles {
+		data, err := readZipFile(zf)
+		if err != nil {
+			onRecipe(nil, err)
+			continue
+		}
+
+		var mr mealieRecipe
+		if err := json.Unmarshal(data, &mr); err != nil {
+			onRecipe(nil, fmt.Errorf("unable to parse '%s': %w", zf.Name, err))
+			continue
+		}
+		recipe := mr.toRecipe()
+		recipe.Filename = withoutExt(path.Base(zf.Name))
+
+		if img, ok := images[path.Dir(zf.Name)]; ok {
+			data, err := readZipFile(img)
+			if err != nil {
+				onRecipe(nil, fmt.Errorf("unable to read '%s': %w", img.Name, err))
+				continue
+			}
+			recipe.Images = append(recipe.Images, data)
+		}
+
+		onRecipe(recipe, nil)
+	}
+
+	return nil
+}
+
+func (mr mealieRecipe) toRecipe() *Recipe {
+	r := &Recipe{
+		ID:         mr.ID,
+		Title:      mr.Name,
+		Link:       mr.OrgURL,
+		Text:       mr.Description,
+		Yield:      schemaYield(mr.RecipeYield),
+		PrepTime:   schemaDuration(mr.PrepTime),
+		CookTime:   schemaDuration(mr.CookTime),
+		TotalTime:  schemaDuration(mr.TotalTime),
+		Nutrition:  schemaNutrition(map[string]any(mr.Nutrition)),
+		Favorite:   mr.Rating >= favoriteRating,
+		Categories: make([]string, 0),
+		Images:     make([]B64Image, 0),
+	}
+	if r.ID == "" {
+		r.ID = mr.OrgURL
+	}
+	if r.CookTime == "" {
+		r.CookTime = schemaDuration(mr.PerformTime)
+	}
+	if date, ok := parseExportDate(mr.DateAdded); ok {
+		r.Date = date
+	}
+
+	for _, tag := range append(mr.RecipeCategory, mr.Tags...) {
+		if !containsFold(r.Categories, tag.Name) {
+			r.Categories = append(r.Categories, tag.Name)
+		}
+	}
+
+	var ingredients []structuredIngredient
+	for _, mi := range mr.RecipeIngredient {
+		if mi.Title != "" {
+			ingredients = append(ingredients, structuredIngredient{Heading: mi.Title})
+		}
+		si := structuredIngredient{Amount: mi.Quantity, Note: mi.Note, Original: mi.Display}
+		if si.Original == "" {
+			si.Original = mi.OriginalText
+		}
+		if mi.DisableAmount && si.Original == "" {
+			si.Original = mi.Note
+		}
+		if mi.Unit != nil {
+			si.Unit = mi.Unit.Name
+		}
+		if mi.Food != nil {
+			si.Food = mi.Food.Name
+		}
+		ingredients = append(ingredients, si)
+	}
+	r.Ingredients = ingredientsFromStructured(ingredients)
+
+	var sections []Section
+	for _, step := range mr.RecipeInstructions {
+		if step.Title != "" || len(sections) == 0 {
+			sections = append(sections, Section{Heading: step.Title})
+		}
+		for _, line := range strings.Split(step.Text, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				sections[len(sections)-1].Lines = append(sections[len(sections)-1].Lines, line)
+			}
+		}
+	}
+	r.Instructions = NewSectionedSequence(sections)
+
+	var notes []string
+	for _, n := range mr.Notes {
+		switch {
+		case n.Title == "":
+			notes = append(notes, n.Text)
+		case n.Text == "":
+			notes = append(notes, n.Title)
+		default:
+			notes = append(notes, n.Title+": "+n.Text)
+		}
+	}
+	r.Notes = strings.Join(notes, "\n")
+
+	return r
+}
+
+func mealieFromRecipe(r *Recipe, slug string) *mealieRecipe {
+	mr := &mealieRecipe{
+		Name:               r.Title,
+		Slug:               slug,
+		Description:        r.Text,
+		RecipeYield:        string(r.Yield),
+		TotalTime:          string(r.TotalTime),
+		PrepTime:           string(r.PrepTime),
+		CookTime:           string(r.CookTime),
+		OrgURL:             r.Link,
+		RecipeCategory:     make([]mealieTag, 0),
+		Tags:               make([]mealieTag, 0),
+		RecipeIngredient:   make([]mealieIngredient, 0),
+		RecipeInstructions: make([]mealieInstruction, 0),
+		Nutrition:          make(map[string]any),
+		Notes:              make([]mealieNote, 0),
+	}
+
+	if mealieUUID.MatchString(r.ID) {
+		mr.ID = r.ID
+	}
+	for _, c := range r.Categories {
+		mr.RecipeCategory = append(mr.RecipeCategory, mealieTag{Name: c, Slug: stringToFilename(c)})
+	}
+	if r.Favorite {
+		mr.Rating = favoriteRating
+	}
+	if r.Date != 0 {
+		mr.DateAdded = r.Date.Time().Format("2006-01-02")
+	}
+
+	// Mealie can't reproduce every line from its parts, so lines are kept whole, as notes
+	heading := ""
+	for _, si := range structuredIngredients(r.Ingredients) {
+		if si.Heading != "" {
+			heading = si.Heading
+			continue
+		}
+		mr.RecipeIngredient = append(mr.RecipeIngredient, mealieIngredient{
+			Title:         heading,
+			Note:          si.Original,
+			Quantity:      si.Amount,
+			DisableAmount: true,
+			Display:       si.Original,
+			OriginalText:  si.Original,
+		})
+		heading = ""
+	}
+
+	for _, s := range r.Instructions.Sections() {
+		for i, line := range s.Lines {
+			step := mealieInstruction{Text: line}
+			if i == 0 {
+				step.Title = s.Heading
+			}
+			mr.RecipeInstructions = append(mr.RecipeInstructions, step)
+		}
+	}
+
+	for key, value := range nutritionToSchema(r.Nutrition) {
+		if key != "@type" {
+			mr.Nutrition[key] = value
+		}
+	}
+	for _, line := range strings.Split(r.Notes, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			mr.Notes = append(mr.Notes, mealieNote{Text: line})
+		}
+	}
+
+	return mr
+}
+
+// MealieExport is a Mealie export zip being written.
+type MealieExport struct {
+	f     *os.File
+	zip   *zip.Writer
+	slugs map[string]bool
+}
+
+// NewMealieExport creates a Mealie export zip file and allows writing new recipes directly to it with .Add().
+func NewMealieExport(dir, name string) (*MealieExport, error) {
+	filename := path.Join(dir, stringToFilename(name)+".zip")
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MealieExport{
+		f:     f,
+		zip:   zip.NewWriter(f),
+		slugs: make(map[string]bool),
+	}, nil
+}
+
+func (me *MealieExport) Close() error {
+	if err := me.zip.Close(); err != nil {
+		return err
+	}
+	return me.f.Close()
+}
+
+func (me *MealieExport) Add(r *Recipe) error {
+	base := stringToFilename(r.Title)
+	if base == "" {
+		base = "recipe"
+	}
+	slug := base
+	for i := 2; me.slugs[slug]; i++ {
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+	me.slugs[slug] = true
+
+	data, err := json.MarshalIndent(mealieFromRecipe(r, slug), "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to convert recipe: %w", err)
+	}
+
+	dir := path.Join("recipes", slug)
+	if err := writeZipFile(me.zip, path.Join(dir, slug+".json"), data); err != nil {
+		return err
+	}
+	if len(r.Images) > 0 {
+		return writeZipFile(me.zip, path.Join(dir, "images", mealieImageName+imageExtension(r.Images[0])), r.Images[0])
+	}
+	return nil
+}
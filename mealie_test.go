@@ -0,0 +1,116 @@
+package mela_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func TestParseMealieExport(t *testing.T) {
+	recipes := openExportFixture(t, mela.ParseMealieExport, "fixtures/mealie/export.zip")
+	if len(recipes) != 1 {
+		t.Fatalf("Incorrect number of recipes: want = 1, got = %d", len(recipes))
+	}
+
+	curry := recipes[0]
+	checks := []struct {
+		field     string
+		want, got any
+	}{
+		{"Filename", "chicken-curry", curry.Filename},
+		{"ID", "5a3c1f0e-8f8b-4a55-9b8a-2f7c3d1e9b10", curry.ID},
+		{"Link", "https://example.com/chicken-curry", curry.Link},
+		{"Ingredients", mela.SectionedSequence("1½ tablespoon oil\n2 onion diced\n# To serve\nRice, to serve"), curry.Ingredients},
+		{"Instructions", mela.SectionedSequence("Fry the onions in the oil until soft.\nAdd the chicken and spices.\n" +
+			"Simmer for 30 minutes.\n# Finishing\nServe over rice."), curry.Instructions},
+		{"Categories", []string{"Dinner", "Spicy"}, curry.Categories},
+		{"Yield", mela.PeopleCount("4 servings"), curry.Yield},
+		{"PrepTime", mela.MaybeDuration("15 mins"), curry.PrepTime},
+		{"CookTime", mela.MaybeDuration("45 mins"), curry.CookTime},
+		{"TotalTime", mela.MaybeDuration("1 hr"), curry.TotalTime},
+		{"Nutrition", "Calories: 450\nFat: 12g", curry.Nutrition},
+		{"Notes", "Freezing: Freezes for a month.\nBetter the next day.", curry.Notes},
+		{"Favorite", true, curry.Favorite},
+		{"Date", mela.NewAppleDate(time.Date(2023, time.March, 5, 0, 0, 0, 0, time.UTC)), curry.Date},
+		{"Images", 1, len(curry.Images)},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.want, c.got) {
+			t.Errorf("Incorrect %s: want = %#v, got = %#v", c.field, c.want, c.got)
+		}
+	}
+}
+
+func TestMealieExport_RoundTrip(t *testing.T) {
+	want := exportRecipe(t)
+
+	dir := t.TempDir()
+	export, err := mela.NewMealieExport(dir, "Round Trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := export.Add(want); err != nil {
+		t.Fatal(err)
+	}
+	if err := export.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	recipes := openExportFixture(t, mela.ParseMealieExport, filepath.Join(dir, "round-trip.zip"))
+	if len(recipes) != 1 {
+		t.Fatalf("Incorrect number of recipes: want = 1, got = %d", len(recipes))
+	}
+
+	got := recipes[0]
+	if got.ID != want.ID || got.Title != want.Title || got.Link != want.Link || got.Text != want.Text ||
+		got.Ingredients != want.Ingredients || got.Instructions != want.Instructions || got.Nutrition != want.Nutrition ||
+		got.Notes != want.Notes ||
+		got.Yield != want.Yield || got.PrepTime != want.PrepTime || got.CookTime != want.CookTime ||
+		got.TotalTime != want.TotalTime || got.Favorite != want.Favorite || !reflect.DeepEqual(got.Categories, want.Categories) {
+		t.Errorf("Recipe changed in round trip: want = %#v, got = %#v", want, got)
+	}
+	// Mealie only keeps the date a recipe was added
+	if wantDate := want.Date.Time().Truncate(24 * time.Hour); !got.Date.Time().Equal(wantDate) {
+		t.Errorf("Incorrect date: want = %v, got = %v", wantDate, got.Date.Time())
+	}
+	if len(got.Images) != 1 || !bytes.Equal(got.Images[0], want.Images[0]) {
+		t.Error("Image changed in round trip")
+	}
+}
+
+func TestMealieExport_IDs(t *testing.T) {
+	cases := map[string]string{
+		"5a3c1f0e-8f8b-4a55-9b8a-2f7c3d1e9b10": "5a3c1f0e-8f8b-4a55-9b8a-2f7c3d1e9b10",
+		"urn:isbn:9780241953242":               "https://example.com/stew",
+		"":                                     "https://example.com/stew",
+	}
+	for id, wantID := range cases {
+		t.Run(id, func(t *testing.T) {
+			r := &mela.Recipe{ID: id, Title: "Stew", Link: "https://example.com/stew"}
+
+			dir := t.TempDir()
+			export, err := mela.NewMealieExport(dir, "IDs")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := export.Add(r); err != nil {
+				t.Fatal(err)
+			}
+			if err := export.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			recipes := openExportFixture(t, mela.ParseMealieExport, filepath.Join(dir, "ids.zip"))
+			if len(recipes) != 1 {
+				t.Fatalf("Incorrect number of recipes: want = 1, got = %d", len(recipes))
+			}
+			if recipes[0].ID != wantID {
+				t.Errorf("Incorrect ID: want = %q, got = %q", wantID, recipes[0].ID)
+			}
+		})
+	}
+}
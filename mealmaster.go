@@ -0,0 +1,291 @@
+package mela
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// UnparsedLine is a line of an imported text file that couldn't be understood. Lines within a recipe are kept as-is
+// where possible (eg. as an ingredient line) so nothing is lost, but should be checked by hand.
+type UnparsedLine struct {
+	// Line is the 1-based line number within the file
+	Line int
+	// Recipe is the title of the recipe the line is part of, if any
+	Recipe string
+	Text   string
+	Reason string
+}
+
+var mealMasterStart = regexp.MustCompile(`(?i)^(?:MMMMM|-----).*meal-?master`)
+var mealMasterEnd = regexp.MustCompile(`^(?:MMMMM|-----)\s*$`)
+var mealMasterHeading = regexp.MustCompile(`^(?:MMMMM|-----)-*\s*(.*?)\s*-*$`)
+var mealMasterHeader = regexp.MustCompile(`^\s*(Title|Categories|Yield|Servings)\s*:\s*(.*)$`)
+
+// mealMasterIngredient matches MealMaster's fixed-column ingredient layout: a 7 character quantity, a 2 character
+// unit and then the ingredient itself.
+var mealMasterIngredient = regexp.MustCompile(`^([\d ./-]{7}) (..) (.*)$`)
+
+// mealMasterUnits maps MealMaster's unit abbreviations onto the words used in Mela ingredient lines.
+var mealMasterUnits = map[string]string{
+	"":   "",
+	"x":  "", // per serving
+	"ea": "", // each
+	"sm": "small", "md": "medium", "lg": "large",
+	"cn": "can", "pk": "packet", "pn": "pinch", "dr": "drop", "ds": "dash", "ct": "carton", "bn": "bunch",
+	"sl": "slice",
+	"t":  "tsp", "ts": "tsp", "T": "tbsp", "tb": "tbsp",
+	"fl": "fl oz", "c": "cup", "pt": "pint", "qt": "quart", "ga": "gallon",
+	"oz": "oz", "lb": "lb",
+	"ml": "ml", "cb": "ml", "cl": "cl", "dl": "dl", "l": "l", "lt": "l",
+	"mg": "mg", "cg": "cg", "dg": "dg", "g": "g", "kg": "kg",
+}
+
+// pluralUnits are the units written differently for quantities above one.
+var pluralUnits = map[string]string{
+	"can": "cans", "packet": "packets", "pinch": "pinches", "drop": "drops", "dash": "dashes", "carton": "cartons",
+	"bunch": "bunches", "slice": "slices", "cup": "cups", "pint": "pints", "quart": "quarts", "gallon": "gallons",
+}
+
+var fractionGlyphs = map[string]string{"1/2": "½", "1/3": "⅓", "2/3": "⅔", "1/4": "¼", "3/4": "¾", "1/8": "⅛"}
+
+type mealMasterState int
+
+const (
+	mealMasterOutside mealMasterState = iota
+	mealMasterHeaders
+	mealMasterIngredients
+	mealMasterDirections
+)
+
+// ParseMealMaster splits a MealMaster text file into its recipes, whether delimited with MMMMM or ----- lines. Any
+// lines that couldn't be understood are returned alongside the recipes.
+func ParseMealMaster(r io.Reader) ([]*Recipe, []UnparsedLine, error) {
+	var recipes []*Recipe
+	var unparsed []UnparsedLine
+
+	var recipe *Recipe
+	var ingredients, directions []Section
+	var paragraph []string
+	var left, right []string
+	state := mealMasterOutside
+	lineNum := 0
+
+	report := func(text, reason string) {
+		title := ""
+		if recipe != nil {
+			title = recipe.Title
+		}
+		unparsed = append(unparsed, UnparsedLine{Line: lineNum, Recipe: title, Text: text, Reason: reason})
+	}
+
+	addSection := func(sections []Section, heading string) []Section {
+		if len(sections) > 0 && len(sections[len(sections)-1].Lines) == 0 && sections[len(sections)-1].Heading == "" {
+			sections[len(sections)-1].Heading = heading
+			return sections
+		}
+		return append(sections, Section{Heading: heading})
+	}
+	addLines := func(sections []Section, lines ...string) []Section {
+		if len(sections) == 0 {
+			sections = append(sections, Section{})
+		}
+		sections[len(sections)-1].Lines = append(sections[len(sections)-1].Lines, lines...)
+		return sections
+	}
+	// Two column ingredient lists are read down the left column and then down the right
+	endColumns := func() {
+		ingredients = addLines(ingredients, append(left, right...)...)
+		left, right = nil, nil
+	}
+	endParagraph := func() {
+		if len(paragraph) > 0 {
+			directions = addLines(directions, strings.Join(paragraph, " "))
+			paragraph = nil
+		}
+	}
+	finish := func() {
+		endColumns()
+		endParagraph()
+		recipe.Ingredients = NewSectionedSequence(ingredients)
+		recipe.Instructions = NewSectionedSequence(directions)
+		if recipe.Title == "" {
+			report("", "recipe has no title")
+		}
+		recipes = append(recipes, recipe)
+		recipe, ingredients, directions = nil, nil, nil
+		state = mealMasterOutside
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), " \t\r\x1a")
+
+		if mealMasterStart.MatchString(line) {
+			if recipe != nil {
+				report(line, "recipe has no end marker")
+				finish()
+			}
+			recipe = &Recipe{Categories: make([]string, 0), Images: make([]B64Image, 0)}
+			state = mealMasterHeaders
+			continue
+		}
+		if state == mealMasterOutside {
+			if strings.TrimSpace(line) != "" {
+				report(line, "outside of any recipe")
+			}
+			continue
+		}
+		if mealMasterEnd.MatchString(line) {
+			finish()
+			continue
+		}
+
+		if m := mealMasterHeading.FindStringSubmatch(line); m != nil {
+			if m[1] == "" {
+				continue
+			}
+			if state == mealMasterDirections {
+				endParagraph()
+				directions = addSection(directions, m[1])
+			} else {
+				endColumns()
+				ingredients = addSection(ingredients, m[1])
+				state = mealMasterIngredients
+			}
+			continue
+		}
+
+		switch state {
+		case mealMasterHeaders:
+			if m := mealMasterHeader.FindStringSubmatch(line); m != nil {
+				applyMealMasterHeader(recipe, m[1], m[2])
+				continue
+			}
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			state = mealMasterIngredients
+			fallthrough
+
+		case mealMasterIngredients:
+			if strings.TrimSpace(line) == "" {
+				endColumns()
+				continue
+			}
+
+			leftText, rightText := line, ""
+			if len(line) > 41 && mealMasterIngredient.MatchString(line[41:]) && strings.TrimSpace(line[39:41]) == "" {
+				leftText, rightText = line[:39], line[41:]
+			}
+
+			var ok bool
+			if left, ok = appendMealMasterIngredient(left, leftText, report); !ok {
+				// The ingredients have ended without a blank line
+				endColumns()
+				state = mealMasterDirections
+				paragraph = append(paragraph, strings.TrimSpace(line))
+				continue
+			}
+			if rightText != "" {
+				right, _ = appendMealMasterIngredient(right, rightText, report)
+			}
+
+		case mealMasterDirections:
+			if strings.TrimSpace(line) == "" {
+				endParagraph()
+				continue
+			}
+			paragraph = append(paragraph, strings.TrimSpace(line))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if recipe != nil {
+		report("", "recipe has no end marker")
+		finish()
+	}
+
+	return recipes, unparsed, nil
+}
+
+func applyMealMasterHeader(r *Recipe, key, value string) {
+	value = strings.TrimSpace(value)
+	switch key {
+	case "Title":
+		r.Title = value
+	case "Categories":
+		for _, c := range strings.Split(value, ",") {
+			if c = strings.TrimSpace(c); c != "" && !strings.EqualFold(c, "None") {
+				r.Categories = append(r.Categories, c)
+			}
+		}
+	case "Yield", "Servings":
+		r.Yield = PeopleCount(value)
+	}
+}
+
+// appendMealMasterIngredient adds a fixed-column ingredient line to lines, returning false if it isn't one.
+func appendMealMasterIngredient(lines []string, text string, report func(text, reason string)) ([]string, bool) {
+	m := mealMasterIngredient.FindStringSubmatch(text)
+	if m == nil {
+		return lines, false
+	}
+
+	qty := strings.TrimSpace(m[1])
+	abbr := strings.TrimSpace(m[2])
+	name := strings.TrimSpace(m[3])
+
+	unit, known := mealMasterUnits[abbr]
+	if !known {
+		if qty == "" && abbr != "" {
+			// Not an ingredient line, eg. an indented direction
+			return lines, false
+		}
+		report(text, "unknown unit '"+abbr+"'")
+		return append(lines, strings.TrimSpace(text)), true
+	}
+
+	// A continuation of the previous ingredient, eg. "-finely chopped"
+	if qty == "" && unit == "" && strings.HasPrefix(name, "-") && len(lines) > 0 {
+		lines[len(lines)-1] += " " + strings.TrimSpace(strings.TrimPrefix(name, "-"))
+		return lines, true
+	}
+
+	return append(lines, joinIngredientParts(mixedFraction(qty), unit, name)), true
+}
+
+// joinIngredientParts builds an ingredient line, pluralising the unit if needed, eg. "2 cups flour".
+func joinIngredientParts(qty, unit, name string) string {
+	if plural, ok := pluralUnits[unit]; ok {
+		if n, err := parseYieldNumber(qty); err == nil && n > 1 {
+			unit = plural
+		}
+	}
+
+	var parts []string
+	for _, p := range []string{qty, unit, name} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// mixedFraction rewrites quantities like "1 1/2" as "1½", which reads better and parses as a single number.
+func mixedFraction(qty string) string {
+	fields := strings.Fields(qty)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	last := fields[len(fields)-1]
+	if glyph, ok := fractionGlyphs[last]; ok {
+		return strings.Join(fields[:len(fields)-1], " ") + glyph
+	}
+	return strings.Join(fields, " ")
+}
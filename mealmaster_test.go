@@ -0,0 +1,54 @@
+package mela_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func TestParseMealMaster(t *testing.T) {
+	f, err := os.Open("fixtures/mealmaster/cookies.mmf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	recipes, unparsed, err := mela.ParseMealMaster(f)
+	if err != nil {
+		t.Fatalf("Unable to parse MealMaster: %v", err)
+	}
+
+	want := []*mela.Recipe{
+		{
+			Title:      "Chocolate Chip Cookies",
+			Categories: []string{"Cookies", "Desserts"},
+			Yield:      "36 cookies",
+			Images:     []mela.B64Image{},
+			Ingredients: "1 cup Butter; softened\n¾ cup Sugar\n1 tsp Vanilla extract\n2 Eggs\n1½ cups Flour\n" +
+				"# TO FINISH\n6 oz Chocolate chips roughly chopped\n1 zz Mystery ingredient",
+			Instructions: "Cream the butter and sugar until light, then beat in the eggs and vanilla.\n" +
+				"Stir in the flour and chocolate chips, spoon onto a baking sheet and bake for 10 minutes.",
+		},
+		{
+			Title:        "Toast",
+			Categories:   []string{},
+			Yield:        "1",
+			Images:       []mela.B64Image{},
+			Ingredients:  "1 slice Bread\n1 tbsp Butter",
+			Instructions: "Toast the bread and butter it.",
+		},
+	}
+	if !reflect.DeepEqual(want, recipes) {
+		t.Errorf("Incorrect recipes:\nwant = %#v\ngot  = %#v", want, recipes)
+	}
+
+	wantUnparsed := []mela.UnparsedLine{
+		{Line: 1, Text: "This file was downloaded from an old BBS.", Reason: "outside of any recipe"},
+		{Line: 16, Recipe: "Chocolate Chip Cookies", Text: "      1 zz Mystery ingredient", Reason: "unknown unit 'zz'"},
+	}
+	if !reflect.DeepEqual(wantUnparsed, unparsed) {
+		t.Errorf("Incorrect unparsed lines:\nwant = %#v\ngot  = %#v", wantUnparsed, unparsed)
+	}
+}
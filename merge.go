@@ -0,0 +1,317 @@
+package mela
+
+import (
+	"crypto/sha256"
+	"strings"
+)
+
+// MergePreference is the version MergeRecipes uses when both versions have changed a field differently.
+type MergePreference int
+
+const (
+	PreferOurs MergePreference = iota
+	PreferTheirs
+)
+
+// MergeConflict is a field (by its JSON name) that both versions of a recipe changed differently. For text fields, it
+// holds only the lines that conflict.
+type MergeConflict struct {
+	Field  string
+	Base   string
+	Ours   string
+	Theirs string
+}
+
+// recipeMerger merges each field of two versions of a recipe, noting conflicts as it goes.
+type recipeMerger struct {
+	base      *Recipe
+	prefer    MergePreference
+	conflicts []MergeConflict
+}
+
+// MergeRecipes merges two versions (ours and theirs) of the same recipe, field by field. If base, the version both were
+// edited from, is given, it's a three-way merge: changes from either side are kept, and fields (or, for text fields,
+// lines) that both sides changed differently are conflicts, resolved by the preference. Without a base, it's a two-way
+// merge: non-empty fields are kept over empty ones, and the preference picks between differing values, except for text
+// fields where neither version contains the other, which are conflicts.
+//
+// In both cases, categories are merged as sets and images by their contents, keeping those added on either side and
+// leaving out those removed on either side.
+func MergeRecipes(base, ours, theirs *Recipe, prefer MergePreference) (*Recipe, []MergeConflict) {
+	m := &recipeMerger{base: base, prefer: prefer}
+	b := base
+	if b == nil {
+		b = &Recipe{}
+	}
+
+	merged := &Recipe{
+		Filename:     m.pickNonEmpty(ours.Filename, theirs.Filename),
+		ID:           m.mergeString("id", b.ID, ours.ID, theirs.ID),
+		Title:        m.mergeString("title", b.Title, ours.Title, theirs.Title),
+		Link:         m.mergeString("link", b.Link, ours.Link, theirs.Link),
+		Text:         m.mergeText("text", b.Text, ours.Text, theirs.Text),
+		Ingredients:  SectionedSequence(m.mergeText("ingredients", string(b.Ingredients), string(ours.Ingredients), string(theirs.Ingredients))),
+		Instructions: SectionedSequence(m.mergeText("instructions", string(b.Instructions), string(ours.Instructions), string(theirs.Instructions))),
+		Nutrition:    m.mergeText("nutrition", b.Nutrition, ours.Nutrition, theirs.Nutrition),
+		Categories:   m.mergeCategories(b.Categories, ours.Categories, theirs.Categories),
+		Notes:        m.mergeText("notes", b.Notes, ours.Notes, theirs.Notes),
+		Images:       m.mergeImages(b.Images, ours.Images, theirs.Images),
+		Yield:        PeopleCount(m.mergeString("yield", string(b.Yield), string(ours.Yield), string(theirs.Yield))),
+		PrepTime:     MaybeDuration(m.mergeString("prepTime", string(b.PrepTime), string(ours.PrepTime), string(theirs.PrepTime))),
+		CookTime:     MaybeDuration(m.mergeString("cookTime", string(b.CookTime), string(ours.CookTime), string(theirs.CookTime))),
+		TotalTime:    MaybeDuration(m.mergeString("totalTime", string(b.TotalTime), string(ours.TotalTime), string(theirs.TotalTime))),
+		Favorite:     m.mergeBool(b.Favorite, ours.Favorite, theirs.Favorite),
+		WantToCook:   m.mergeBool(b.WantToCook, ours.WantToCook, theirs.WantToCook),
+		Date:         m.mergeDate(b.Date, ours.Date, theirs.Date),
+	}
+
+	return merged, m.conflicts
+}
+
+func (m *recipeMerger) pick(ours, theirs string) string {
+	if m.prefer == PreferTheirs {
+		return theirs
+	}
+	return ours
+}
+
+func (m *recipeMerger) pickNonEmpty(ours, theirs string) string {
+	switch {
+	case ours == "":
+		return theirs
+	case theirs == "":
+		return ours
+	}
+	return m.pick(ours, theirs)
+}
+
+func (m *recipeMerger) conflict(field, base, ours, theirs string) string {
+	m.conflicts = append(m.conflicts, MergeConflict{Field: field, Base: base, Ours: ours, Theirs: theirs})
+	return m.pick(ours, theirs)
+}
+
+func (m *recipeMerger) mergeString(field, base, ours, theirs string) string {
+	switch {
+	case ours == theirs:
+		return ours
+	case m.base == nil:
+		return m.pickNonEmpty(ours, theirs)
+	case ours == base:
+		return theirs
+	case theirs == base:
+		return ours
+	}
+	return m.conflict(field, base, ours, theirs)
+}
+
+func (m *recipeMerger) mergeBool(base, ours, theirs bool) bool {
+	switch {
+	case ours == theirs:
+		return ours
+	case m.base == nil:
+		return true
+	case ours == base:
+		return theirs
+	}
+	return ours
+}
+
+func (m *recipeMerger) mergeDate(base, ours, theirs AppleDate) AppleDate {
+	switch {
+	case ours == theirs:
+		return ours
+	case m.base != nil && ours == base:
+		return theirs
+	case m.base != nil && theirs == base:
+		return ours
+	case ours == 0:
+		return theirs
+	case theirs == 0:
+		return ours
+	}
+	// Without a base, the earlier date is when the recipe was first added
+	if m.base == nil {
+		return min(ours, theirs)
+	}
+	if m.prefer == PreferTheirs {
+		return theirs
+	}
+	return ours
+}
+
+// mergeText merges multi-line text, line by line.
+func (m *recipeMerger) mergeText(field, base, ours, theirs string) string {
+	if ours == theirs {
+		return ours
+	}
+	if m.base == nil {
+		switch {
+		case containsLines(ours, theirs):
+			return ours
+		case containsLines(theirs, ours):
+			return theirs
+		}
+		return m.conflict(field, "", ours, theirs)
+	}
+
+	var merged []string
+	for _, h := range diff3(splitLines(base), splitLines(ours), splitLines(theirs)) {
+		switch {
+		case equalLines(h.ours, h.theirs), equalLines(h.theirs, h.base):
+			merged = append(merged, h.ours...)
+		case equalLines(h.ours, h.base):
+			merged = append(merged, h.theirs...)
+		default:
+			m.conflict(field, strings.Join(h.base, "\n"), strings.Join(h.ours, "\n"), strings.Join(h.theirs, "\n"))
+			if m.prefer == PreferTheirs {
+				merged = append(merged, h.theirs...)
+			} else {
+				merged = append(merged, h.ours...)
+			}
+		}
+	}
+	return strings.Join(merged, "\n")
+}
+
+func (m *recipeMerger) mergeCategories(base, ours, theirs []string) []string {
+	merged := make([]string, 0)
+	for _, c := range append(append([]string{}, ours...), theirs...) {
+		if containsFold(merged, c) {
+			continue
+		}
+		// Categories in the base that either side has removed stay removed
+		if m.base != nil && containsFold(base, c) && (!containsFold(ours, c) || !containsFold(theirs, c)) {
+			continue
+		}
+		merged = append(merged, c)
+	}
+	return merged
+}
+
+func (m *recipeMerger) mergeImages(base, ours, theirs []B64Image) []B64Image {
+	hashes := func(imgs []B64Image) map[[sha256.Size]byte]bool {
+		set := make(map[[sha256.Size]byte]bool)
+		for _, img := range imgs {
+			set[sha256.Sum256(img)] = true
+		}
+		return set
+	}
+	baseSet, oursSet, theirsSet := hashes(base), hashes(ours), hashes(theirs)
+
+	merged := make([]B64Image, 0)
+	seen := make(map[[sha256.Size]byte]bool)
+	for _, img := range append(append([]B64Image{}, ours...), theirs...) {
+		sum := sha256.Sum256(img)
+		if seen[sum] {
+			continue
+		}
+		seen[sum] = true
+		if m.base != nil && baseSet[sum] && (!oursSet[sum] || !theirsSet[sum]) {
+			continue
+		}
+		merged = append(merged, img)
+	}
+	return merged
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// containsLines checks if every line of sub is in text, in the same order.
+func containsLines(text, sub string) bool {
+	lines := splitLines(text)
+	i := 0
+	for _, line := range splitLines(sub) {
+		for i < len(lines) && lines[i] != line {
+			i++
+		}
+		if i == len(lines) {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// diff3Hunk is a part of the base, and what each side has in its place.
+type diff3Hunk struct {
+	base, ours, theirs []string
+}
+
+// diff3 splits three versions of some lines into hunks, between the base lines that both sides kept unchanged.
+func diff3(base, ours, theirs []string) []diff3Hunk {
+	toOurs, toTheirs := matchLines(base, ours), matchLines(base, theirs)
+
+	var hunks []diff3Hunk
+	b0, o0, t0 := 0, 0, 0
+	for b := 0; b <= len(base); b++ {
+		// The end of all three is a final sync point
+		o, t := len(ours), len(theirs)
+		if b < len(base) {
+			o, t = toOurs[b], toTheirs[b]
+			if o < 0 || t < 0 {
+				continue
+			}
+		}
+
+		if b > b0 || o > o0 || t > t0 {
+			hunks = append(hunks, diff3Hunk{base: base[b0:b], ours: ours[o0:o], theirs: theirs[t0:t]})
+		}
+		if b < len(base) {
+			hunks = append(hunks, diff3Hunk{base: base[b : b+1], ours: ours[o : o+1], theirs: theirs[t : t+1]})
+		}
+		b0, o0, t0 = b+1, o+1, t+1
+	}
+	return hunks
+}
+
+// matchLines finds the longest common subsequence of lines in a and b, giving the position in b that each line of a
+// matches, or -1 for lines that aren't in it.
+func matchLines(a, b []string) []int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	matches := make([]int, len(a))
+	i, j := 0, 0
+	for i < len(a) {
+		switch {
+		case j < len(b) && a[i] == b[j]:
+			matches[i] = j
+			i++
+			j++
+		case j < len(b) && lcs[i][j+1] >= lcs[i+1][j]:
+			j++
+		default:
+			matches[i] = -1
+			i++
+		}
+	}
+	return matches
+}
@@ -0,0 +1,103 @@
+package mela_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func TestMergeRecipes(t *testing.T) {
+	base := &mela.Recipe{
+		Title:        "Lentil soup",
+		Ingredients:  "1 onion\n200g red lentils\n1l stock",
+		Instructions: "Fry the onion.\nAdd the lentils and stock.\nSimmer for 20 mins.",
+		Notes:        "Freezes well.",
+		Categories:   []string{"Soup", "Vegan"},
+		Images:       []mela.B64Image{[]byte("photo 1"), []byte("photo 2")},
+		Yield:        "4",
+	}
+
+	ours := *base
+	ours.Title = "Red lentil soup"
+	ours.Ingredients = "1 onion\n2 garlic cloves\n200g red lentils\n1l stock"
+	ours.Notes = "Freezes well for a month."
+	ours.Categories = []string{"Soup", "Vegan", "Winter"}
+	ours.Favorite = true
+
+	theirs := *base
+	theirs.Instructions = "Fry the onion.\nAdd the lentils and stock.\nSimmer for 25 mins."
+	theirs.Ingredients = "1 onion\n200g red lentils\n1l stock\n1 lemon"
+	theirs.Notes = "Freezes for 3 months."
+	theirs.Categories = []string{"Soup"}
+	theirs.Images = []mela.B64Image{[]byte("photo 1"), []byte("photo 2"), []byte("photo 1"), []byte("photo 3")}
+	theirs.Yield = "6"
+
+	got, conflicts := mela.MergeRecipes(base, &ours, &theirs, mela.PreferOurs)
+
+	want := &mela.Recipe{
+		Title:        "Red lentil soup",
+		Ingredients:  "1 onion\n2 garlic cloves\n200g red lentils\n1l stock\n1 lemon",
+		Instructions: "Fry the onion.\nAdd the lentils and stock.\nSimmer for 25 mins.",
+		Notes:        "Freezes well for a month.",
+		Categories:   []string{"Soup", "Winter"},
+		Images:       []mela.B64Image{[]byte("photo 1"), []byte("photo 2"), []byte("photo 3")},
+		Yield:        "6",
+		Favorite:     true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Incorrect merge:\nwant = %#v\ngot  = %#v", want, got)
+	}
+
+	wantConflicts := []mela.MergeConflict{
+		{Field: "notes", Base: "Freezes well.", Ours: "Freezes well for a month.", Theirs: "Freezes for 3 months."},
+	}
+	if !reflect.DeepEqual(conflicts, wantConflicts) {
+		t.Errorf("Incorrect conflicts:\nwant = %#v\ngot  = %#v", wantConflicts, conflicts)
+	}
+
+	got, _ = mela.MergeRecipes(base, &ours, &theirs, mela.PreferTheirs)
+	if got.Notes != theirs.Notes {
+		t.Errorf("Preferring theirs, wanted their notes, got %q", got.Notes)
+	}
+}
+
+func TestMergeRecipes_TwoWay(t *testing.T) {
+	ours := &mela.Recipe{
+		Title:       "Flapjacks",
+		Ingredients: "250g oats\n125g butter",
+		Notes:       "Kids love these.",
+		Categories:  []string{"Baking"},
+		Date:        200,
+	}
+	theirs := &mela.Recipe{
+		Title:       "Golden flapjacks",
+		Link:        "https://example.com/flapjacks",
+		Ingredients: "250g oats\n125g butter\n4 tbsp golden syrup",
+		Notes:       "Use a 20cm tin.",
+		Categories:  []string{"baking", "Snacks"},
+		Date:        100,
+		WantToCook:  true,
+	}
+
+	got, conflicts := mela.MergeRecipes(nil, ours, theirs, mela.PreferOurs)
+
+	want := &mela.Recipe{
+		Title:       "Flapjacks",
+		Link:        "https://example.com/flapjacks",
+		Ingredients: "250g oats\n125g butter\n4 tbsp golden syrup",
+		Notes:       "Kids love these.",
+		Categories:  []string{"Baking", "Snacks"},
+		Images:      []mela.B64Image{},
+		Date:        100,
+		WantToCook:  true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Incorrect merge:\nwant = %#v\ngot  = %#v", want, got)
+	}
+
+	wantConflicts := []mela.MergeConflict{{Field: "notes", Ours: "Kids love these.", Theirs: "Use a 20cm tin."}}
+	if !reflect.DeepEqual(conflicts, wantConflicts) {
+		t.Errorf("Incorrect conflicts:\nwant = %#v\ngot  = %#v", wantConflicts, conflicts)
+	}
+}
@@ -0,0 +1,300 @@
+package mela
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetadataColumns are the columns of a metadata spreadsheet, as written by WriteMetadataCSV.
+var MetadataColumns = []string{
+	"filename", "id", "title", "link", "yield", "prepTime", "cookTime", "totalTime", "categories",
+	"isbn", "pages", "recipeNumber", "favorite", "date",
+}
+
+var ErrNoMetadataKey = errors.New("the spreadsheet needs an 'id' or 'filename' column to match rows to recipes")
+
+const metadataDateFormat = time.RFC3339
+
+const metadataCategorySeparator = ";"
+
+// WriteMetadataCSV writes the scalar fields of each recipe (see MetadataColumns) as a row of a spreadsheet, separated by
+// comma: ',' for CSV or '\t' for TSV. Categories are listed in one cell, separated by semicolons (category names can
+// contain commas).
+func WriteMetadataCSV(w io.Writer, recipes []*Recipe, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+
+	if err := cw.Write(MetadataColumns); err != nil {
+		return err
+	}
+	for _, r := range recipes {
+		if err := cw.Write(metadataRow(r)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func metadataRow(r *Recipe) []string {
+	var isbn, pages, recipeNumber string
+	if book := r.Book(); book != nil {
+		isbn = book.ISBN13
+		if len(book.Pages) > 0 {
+			pages = book.Pages.String()
+		}
+		if book.RecipeNumber > 0 {
+			recipeNumber = strconv.FormatUint(uint64(book.RecipeNumber), 10)
+		}
+	}
+	return []string{
+		r.Filename, r.ID, r.Title, r.Link, string(r.Yield),
+		string(r.PrepTime), string(r.CookTime), string(r.TotalTime), strings.Join(r.Categories, metadataCategorySeparator+" "),
+		isbn, pages, recipeNumber, strconv.FormatBool(r.Favorite), metadataDate(r),
+	}
+}
+
+func metadataDate(r *Recipe) string {
+	if r.Date == 0 {
+		return ""
+	}
+	return r.Date.Time().Format(metadataDateFormat)
+}
+
+// ApplyMetadataCSV updates recipes from a (possibly edited) spreadsheet written by WriteMetadataCSV, as CSV or TSV.
+// Each row updates the recipe with its id or, failing that, its filename. Only the columns present are changed, and a
+// recipe's ingredients, instructions and images are never touched. Rows that don't match a recipe, have invalid values
+// or can't be parsed are left out and returned.
+func ApplyMetadataCSV(r io.Reader, recipes []*Recipe) ([]UnparsedLine, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(br.Size())
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, err
+	}
+
+	cr := csv.NewReader(br)
+	if firstLine, _, _ := bytes.Cut(header, []byte("\n")); bytes.ContainsRune(firstLine, '\t') {
+		cr.Comma = '\t'
+	}
+	cr.FieldsPerRecord = -1
+
+	columns, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read header: %w", err)
+	}
+	index := make(map[string]int)
+	for i, col := range columns {
+		for _, known := range MetadataColumns {
+			if strings.EqualFold(strings.TrimSpace(col), known) {
+				index[known] = i
+			}
+		}
+	}
+	_, hasID := index["id"]
+	_, hasFilename := index["filename"]
+	if !hasID && !hasFilename {
+		return nil, ErrNoMetadataKey
+	}
+
+	byID := make(map[string]*Recipe)
+	byFilename := make(map[string]*Recipe)
+	for i := len(recipes) - 1; i >= 0; i-- {
+		byID[recipes[i].ID] = recipes[i]
+		byFilename[recipes[i].Filename] = recipes[i]
+	}
+
+	var unmatched []UnparsedLine
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		// Malformed rows are reported, and the rows after them still applied
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			unmatched = append(unmatched, UnparsedLine{Line: parseErr.Line, Text: strings.Join(record, string(cr.Comma)), Reason: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return unmatched, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		row := metadataCells{record: record, index: index}
+		report := func(title, reason string) {
+			unmatched = append(unmatched, UnparsedLine{Line: line, Recipe: title, Text: strings.Join(record, string(cr.Comma)), Reason: reason})
+		}
+
+		recipe, ok := byID[row.get("id")]
+		if !ok || row.get("id") == "" {
+			recipe, ok = byFilename[row.get("filename")]
+		}
+		if !ok || (row.get("id") == "" && row.get("filename") == "") {
+			report(row.get("title"), "no recipe has this id or filename")
+			continue
+		}
+
+		updated, err := row.apply(recipe)
+		if err != nil {
+			report(recipe.Title, err.Error())
+			continue
+		}
+		// Later rows match the recipe by its new id
+		if updated.ID != recipe.ID {
+			delete(byID, recipe.ID)
+			byID[updated.ID] = recipe
+		}
+		*recipe = *updated
+	}
+
+	return unmatched, nil
+}
+
+// metadataCells is a row of a metadata spreadsheet, looked up by column name.
+type metadataCells struct {
+	record []string
+	index  map[string]int
+}
+
+func (mc metadataCells) has(col string) bool {
+	i, ok := mc.index[col]
+	return ok && i < len(mc.record)
+}
+
+func (mc metadataCells) get(col string) string {
+	if !mc.has(col) {
+		return ""
+	}
+	return strings.TrimSpace(mc.record[mc.index[col]])
+}
+
+// apply returns a copy of the recipe with the row's values, or an error if any of them are invalid.
+func (mc metadataCells) apply(current *Recipe) (*Recipe, error) {
+	r := *current
+
+	strs := []struct {
+		col   string
+		field *string
+	}{
+		{"title", &r.Title},
+		{"link", &r.Link},
+		{"yield", (*string)(&r.Yield)},
+		{"prepTime", (*string)(&r.PrepTime)},
+		{"cookTime", (*string)(&r.CookTime)},
+		{"totalTime", (*string)(&r.TotalTime)},
+	}
+	for _, s := range strs {
+		if mc.has(s.col) {
+			*s.field = mc.get(s.col)
+		}
+	}
+
+	if mc.has("categories") {
+		r.Categories = make([]string, 0)
+		for _, c := range strings.Split(mc.get("categories"), metadataCategorySeparator) {
+			if c = strings.TrimSpace(c); c != "" {
+				r.Categories = append(r.Categories, c)
+			}
+		}
+	}
+
+	if mc.has("favorite") {
+		fav, err := parseMetadataBool(mc.get("favorite"))
+		if err != nil {
+			return nil, err
+		}
+		r.Favorite = fav
+	}
+
+	// Dates are only changed when edited, as the spreadsheet holds them to the second
+	if date := mc.get("date"); mc.has("date") && date != metadataDate(current) {
+		if date == "" {
+			r.Date = 0
+		} else if d, ok := parseExportDate(date); ok {
+			r.Date = d
+		} else {
+			return nil, fmt.Errorf("invalid date '%s'", date)
+		}
+	}
+
+	if err := mc.applyID(&r, current); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// applyID sets the recipe's ID from the id column or, if that's unchanged, from the book columns.
+func (mc metadataCells) applyID(r, current *Recipe) error {
+	if id := mc.get("id"); mc.has("id") && id != current.ID {
+		if id == "" {
+			return errors.New("the id can't be removed")
+		}
+		r.ID = id
+		return nil
+	}
+	if !mc.has("isbn") && !mc.has("pages") && !mc.has("recipeNumber") {
+		return nil
+	}
+
+	var book Book
+	if b := current.Book(); b != nil {
+		book = *b
+	}
+	if mc.has("isbn") {
+		book.ISBN13 = mc.get("isbn")
+	}
+	if pages := mc.get("pages"); mc.has("pages") {
+		book.Pages = nil
+		if pages != "" {
+			p, err := ParsePages(pages)
+			if err != nil {
+				return fmt.Errorf("invalid pages '%s': %w", pages, err)
+			}
+			book.Pages = p
+		}
+	}
+	if n := mc.get("recipeNumber"); mc.has("recipeNumber") {
+		book.RecipeNumber = 0
+		if n != "" {
+			num, err := strconv.ParseUint(n, 10, 32)
+			if err != nil {
+				return fmt.Errorf("invalid recipe number '%s'", n)
+			}
+			book.RecipeNumber = uint(num)
+		}
+	}
+
+	if book.ISBN13 == "" {
+		if current.Book() != nil {
+			return errors.New("the isbn was removed, but the id still refers to the book")
+		}
+		return nil
+	}
+	var updated Recipe
+	if err := updated.SetBook(book.ISBN13, book.Pages, book.RecipeNumber); err != nil {
+		return fmt.Errorf("invalid isbn '%s': %w", book.ISBN13, err)
+	}
+	if cur := current.Book(); cur == nil || !reflect.DeepEqual(*cur, *updated.Book()) {
+		r.ID = updated.ID
+	}
+	return nil
+}
+
+func parseMetadataBool(str string) (bool, error) {
+	switch strings.ToLower(str) {
+	case "true", "yes", "y", "1", "x", "✓":
+		return true, nil
+	case "false", "no", "n", "0", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid favorite '%s', use true or false", str)
+}
@@ -0,0 +1,153 @@
+package mela_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func TestWriteMetadataCSV(t *testing.T) {
+	recipes, err := mela.Open("fixtures/a+b.melarecipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recipes[0].SetBook("9781234567897", mela.Pages{{"12", "13"}}, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := mela.WriteMetadataCSV(&buf, recipes, '\t'); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"filename\tid\ttitle\tlink\tyield\tprepTime\tcookTime\ttotalTime\tcategories\tisbn\tpages\trecipeNumber\tfavorite\tdate",
+		"B title\turn:isbn:9781234567897#pages=12-13&recipe=2\tB title\thttps://example.com/b\t2\t2min\t2hour\t\tb; bb\t9781234567897\t12-13\t2\tfalse\t2022-08-19T07:04:45Z",
+		"A title\ta\tA title\thttps://example.com/a\t1\t1min\t1hour\t\ta; aa; aaa\t\t\t\tfalse\t2022-08-19T07:04:38Z",
+	}
+	if !reflect.DeepEqual(want, lines) {
+		t.Errorf("Incorrect spreadsheet:\nwant = %q\ngot  = %q", want, lines)
+	}
+}
+
+func TestApplyMetadataCSV(t *testing.T) {
+	recipes, err := mela.Open("fixtures/a+b.melarecipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, a := recipes[0], recipes[1]
+	wantIngredients, wantDate := a.Ingredients, a.Date
+
+	sheet := `Filename,ID,Title,Categories,Favorite,ISBN,Pages,Date
+A title,a,A new title,x; y,yes,,,2022-08-19T07:04:38Z
+B title,renamed,B title,b,no,,,2022-08-19T07:04:45Z
+Gone,b,,,,,,
+Missing,missing,Nope,,,,,
+A title,a,A title,,maybe,,,
+A title,a,A title,,,9781234567897,,not a date
+`
+	unmatched, err := mela.ApplyMetadataCSV(strings.NewReader(sheet), recipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checks := []struct {
+		field     string
+		want, got any
+	}{
+		{"A title", "A new title", a.Title},
+		{"A categories", []string{"x", "y"}, a.Categories},
+		{"A favorite", true, a.Favorite},
+		{"A date unchanged", wantDate, a.Date},
+		{"A ingredients unchanged", wantIngredients, a.Ingredients},
+		{"A link unchanged", "https://example.com/a", a.Link},
+		{"B id", "renamed", b.ID},
+		{"B categories", []string{"b"}, b.Categories},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.want, c.got) {
+			t.Errorf("Incorrect %s: want = %#v, got = %#v", c.field, c.want, c.got)
+		}
+	}
+
+	wantUnmatched := []mela.UnparsedLine{
+		{Line: 4, Recipe: "", Text: "Gone,b,,,,,,", Reason: "no recipe has this id or filename"},
+		{Line: 5, Recipe: "Nope", Text: "Missing,missing,Nope,,,,,", Reason: "no recipe has this id or filename"},
+		{Line: 6, Recipe: "A new title", Text: "A title,a,A title,,maybe,,,", Reason: "invalid favorite 'maybe', use true or false"},
+		{Line: 7, Recipe: "A new title", Text: "A title,a,A title,,,9781234567897,,not a date", Reason: "invalid date 'not a date'"},
+	}
+	if !reflect.DeepEqual(wantUnmatched, unmatched) {
+		t.Errorf("Incorrect unmatched rows:\nwant = %#v\ngot  = %#v", wantUnmatched, unmatched)
+	}
+}
+
+func TestMetadataCSV_RoundTripCategories(t *testing.T) {
+	recipes := []*mela.Recipe{{ID: "a", Categories: []string{"Quick, easy", "Dinner"}}}
+
+	var buf bytes.Buffer
+	if err := mela.WriteMetadataCSV(&buf, recipes, ','); err != nil {
+		t.Fatal(err)
+	}
+	recipes[0].Categories = nil
+
+	unmatched, err := mela.ApplyMetadataCSV(&buf, recipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unmatched) != 0 {
+		t.Errorf("Unexpected unmatched rows: %#v", unmatched)
+	}
+	if want := []string{"Quick, easy", "Dinner"}; !reflect.DeepEqual(want, recipes[0].Categories) {
+		t.Errorf("Incorrect categories: want = %#v, got = %#v", want, recipes[0].Categories)
+	}
+}
+
+func TestApplyMetadataCSV_Book(t *testing.T) {
+	recipes, err := mela.Open("fixtures/a+b.melarecipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sheet := "filename\tisbn\tpages\trecipeNumber\nA title\t123456789X\t45\t3\n"
+	unmatched, err := mela.ApplyMetadataCSV(strings.NewReader(sheet), recipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unmatched) != 0 {
+		t.Errorf("Unexpected unmatched rows: %#v", unmatched)
+	}
+	if want := "urn:isbn:9781234567897#pages=45&recipe=3"; recipes[1].ID != want {
+		t.Errorf("Incorrect ID: want = %s, got = %s", want, recipes[1].ID)
+	}
+}
+
+func TestApplyMetadataCSV_Malformed(t *testing.T) {
+	recipes, err := mela.Open("fixtures/a+b.melarecipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sheet := "id,title\nb\"x,Bad\na,A new title\n"
+	unmatched, err := mela.ApplyMetadataCSV(strings.NewReader(sheet), recipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(unmatched) != 1 || unmatched[0].Line != 2 || unmatched[0].Reason != csv.ErrBareQuote.Error() {
+		t.Errorf("Expected the malformed row to be reported, got %#v", unmatched)
+	}
+	if recipes[1].Title != "A new title" {
+		t.Errorf("Expected rows after the malformed one to be applied, got title %s", recipes[1].Title)
+	}
+}
+
+func TestApplyMetadataCSV_NoKey(t *testing.T) {
+	if _, err := mela.ApplyMetadataCSV(strings.NewReader("title\nA\n"), nil); err != mela.ErrNoMetadataKey {
+		t.Errorf("Expected ErrNoMetadataKey, got %v", err)
+	}
+}
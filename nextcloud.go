@@ -0,0 +1,203 @@
+package mela
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const nextcloudRecipeFile = "recipe.json"
+const nextcloudImageFile = "full.jpg"
+const nextcloudDateFormat = "2006-01-02T15:04:05-0700"
+
+// nextcloudHeading matches the "## " prefix Nextcloud Cookbook uses for headings within its ingredient list.
+var nextcloudHeading = regexp.MustCompile(`^#+\s+`)
+
+// nextcloudRecipe is the recipe.json written by Nextcloud Cookbook: a schema.org Recipe, with some properties
+// restricted to a single type.
+type nextcloudRecipe struct {
+	Context            string            `json:"@context"`
+	Type               string            `json:"@type"`
+	Name               string            `json:"name"`
+	Description        string            `json:"description"`
+	URL                string            `json:"url"`
+	Image              string            `json:"image"`
+	PrepTime           string            `json:"prepTime,omitempty"`
+	CookTime           string            `json:"cookTime,omitempty"`
+	TotalTime          string            `json:"totalTime,omitempty"`
+	RecipeCategory     string            `json:"recipeCategory"`
+	Keywords           string            `json:"keywords"`
+	RecipeYield        int               `json:"recipeYield"`
+	Tool               []string          `json:"tool"`
+	RecipeIngredient   []string          `json:"recipeIngredient"`
+	RecipeInstructions []string          `json:"recipeInstructions"`
+	Nutrition          map[string]string `json:"nutrition"`
+	DateCreated        string            `json:"dateCreated,omitempty"`
+}
+
+// ParseNextcloudRecipe reads the Nextcloud Cookbook recipe in the folder dir of fsys, from its recipe.json and, if
+// present, its full.jpg photo.
+func ParseNextcloudRecipe(fsys fs.FS, dir string) (*Recipe, error) {
+	data, err := fs.ReadFile(fsys, path.Join(dir, nextcloudRecipeFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var n schemaNode
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", nextcloudRecipeFile, err)
+	}
+
+	// Remote image URLs are ignored, as the photo itself is alongside
+	delete(n, "image")
+	r := recipeFromSchema(n)
+	r.Filename = path.Base(dir)
+	r.Ingredients = nextcloudSections(r.Ingredients)
+	r.Instructions = nextcloudSections(r.Instructions)
+
+	for _, kw := range strings.Split(schemaString(n["keywords"]), ",") {
+		if kw = strings.TrimSpace(kw); kw != "" && !containsFold(r.Categories, kw) {
+			r.Categories = append(r.Categories, kw)
+		}
+	}
+	if date, ok := parseExportDate(schemaString(n["dateCreated"])); ok {
+		r.Date = date
+	}
+
+	img, err := fs.ReadFile(fsys, path.Join(dir, nextcloudImageFile))
+	switch {
+	case err == nil:
+		r.Images = append(r.Images, img)
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("unable to read photo: %w", err)
+	}
+
+	return r, nil
+}
+
+// ParseNextcloudRecipes finds every Nextcloud Cookbook recipe folder in fsys (eg. the Recipes folder, or an unzipped
+// export of it), calling the onRecipe func for each, as it is parsed.
+func ParseNextcloudRecipes(fsys fs.FS, onRecipe func(*Recipe, error)) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != nextcloudRecipeFile {
+			return nil
+		}
+
+		r, err := ParseNextcloudRecipe(fsys, path.Dir(p))
+		if err != nil {
+			onRecipe(nil, fmt.Errorf("unable to parse '%s': %w", p, err))
+			return nil
+		}
+		onRecipe(r, nil)
+		return nil
+	})
+}
+
+// nextcloudSections turns Nextcloud Cookbook's "## " headings into Mela's.
+func nextcloudSections(ss SectionedSequence) SectionedSequence {
+	lines := strings.Split(string(ss), "\n")
+	for i, line := range lines {
+		if loc := nextcloudHeading.FindStringIndex(line); loc != nil {
+			lines[i] = "# " + line[loc[1]:]
+		}
+	}
+	return SectionedSequence(strings.Join(lines, "\n"))
+}
+
+// SaveNextcloud writes the recipe as a Nextcloud Cookbook folder within dir, holding its recipe.json and its first
+// image as full.jpg. It returns the path of the folder. Nextcloud Cookbook has nowhere to keep notes or more than one
+// image, so these are left out.
+func (r *Recipe) SaveNextcloud(dir string) (string, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return "", fmt.Errorf("output directory '%s' does not exist", dir)
+	}
+
+	name := r.Filename
+	if name == "" {
+		name = stringToFilename(r.Title)
+	}
+	folder := filepath.Join(dir, filepath.Base(name))
+	if err := os.Mkdir(folder, 0755); err != nil {
+		return "", err
+	}
+
+	nr := nextcloudRecipe{
+		Context:            "http://schema.org",
+		Type:               "Recipe",
+		Name:               r.Title,
+		Description:        r.Text,
+		URL:                r.Link,
+		PrepTime:           isoDuration(r.PrepTime),
+		CookTime:           isoDuration(r.CookTime),
+		TotalTime:          isoDuration(r.TotalTime),
+		Tool:               make([]string, 0),
+		RecipeIngredient:   nextcloudLines(r.Ingredients),
+		RecipeInstructions: nextcloudLines(r.Instructions),
+		Nutrition:          nutritionToSchema(r.Nutrition),
+	}
+	if nr.Nutrition == nil {
+		nr.Nutrition = map[string]string{"@type": "NutritionInformation"}
+	}
+	if len(r.Categories) > 0 {
+		nr.RecipeCategory = r.Categories[0]
+		nr.Keywords = strings.Join(r.Categories[1:], ",")
+	}
+	if y, err := r.Yield.Yield(); err == nil {
+		nr.RecipeYield = int(math.Round(y.Max))
+	}
+	if r.Date != 0 {
+		nr.DateCreated = r.Date.Time().Format(nextcloudDateFormat)
+	}
+
+	if len(r.Images) > 0 {
+		img, err := jpegImage(r.Images[0])
+		if err != nil {
+			return "", fmt.Errorf("unable to convert image: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(folder, nextcloudImageFile), img, 0644); err != nil {
+			return "", fmt.Errorf("unable to write image file: %w", err)
+		}
+		nr.Image = nextcloudImageFile
+	}
+
+	data, err := json.MarshalIndent(nr, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal recipe: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(folder, nextcloudRecipeFile), data, 0644); err != nil {
+		return "", fmt.Errorf("unable to write recipe file: %w", err)
+	}
+
+	return folder, nil
+}
+
+// nextcloudLines lists a sequence's lines, with headings in the form Nextcloud Cookbook uses.
+func nextcloudLines(ss SectionedSequence) []string {
+	lines := make([]string, 0)
+	for _, s := range ss.Sections() {
+		if s.Heading != "" {
+			lines = append(lines, "## "+s.Heading)
+		}
+		lines = append(lines, s.Lines...)
+	}
+	return lines
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package mela_test
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+// exportRecipe has every field the recipe manager exports can hold, for round trip tests.
+func exportRecipe(t *testing.T) *mela.Recipe {
+	t.Helper()
+	img, err := os.ReadFile("fixtures/nextcloud/Banana Bread/full.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &mela.Recipe{
+		ID:           "https://example.com/stew",
+		Title:        "Winter Stew",
+		Link:         "https://example.com/stew",
+		Text:         "Hearty.",
+		Ingredients:  "500g beef, diced\n2 carrots\n# Dumplings\n100g suet\n200g self-raising flour",
+		Instructions: "Brown the beef.\nAdd the carrots and simmer.\n# Dumplings\nMix the suet and flour.\nAdd to the stew for 20 minutes.",
+		Nutrition:    "Calories: 600",
+		Categories:   []string{"Dinner", "Winter"},
+		Notes:        "Better the next day.",
+		Images:       []mela.B64Image{img},
+		Yield:        "6 servings",
+		PrepTime:     "20 mins",
+		CookTime:     "2 hrs",
+		TotalTime:    "2 hrs 20 mins",
+		Favorite:     true,
+		Date:         mela.NewAppleDate(time.Date(2023, time.April, 1, 12, 30, 0, 0, time.UTC)),
+	}
+}
+
+func TestParseNextcloudRecipes(t *testing.T) {
+	var recipes []*mela.Recipe
+	err := mela.ParseNextcloudRecipes(os.DirFS("fixtures/nextcloud"), func(r *mela.Recipe, err error) {
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		recipes = append(recipes, r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recipes) != 2 {
+		t.Fatalf("Incorrect number of recipes: want = 2, got = %d", len(recipes))
+	}
+
+	bread := recipes[0]
+	checks := []struct {
+		field     string
+		want, got any
+	}{
+		{"Filename", "Banana Bread", bread.Filename},
+		{"Link", "https://example.com/banana-bread", bread.Link},
+		{"Ingredients", mela.SectionedSequence("3 ripe bananas\n75g butter, melted\n150g plain flour\n# Topping\n1 tbsp demerara sugar"), bread.Ingredients},
+		{"Categories", []string{"Baking", "bananas", "cake"}, bread.Categories},
+		{"Yield", mela.PeopleCount("8 servings"), bread.Yield},
+		{"PrepTime", mela.MaybeDuration("15 mins"), bread.PrepTime},
+		{"CookTime", mela.MaybeDuration("1 hr"), bread.CookTime},
+		{"Nutrition", "Calories: 250 kcal", bread.Nutrition},
+		{"Date", mela.NewAppleDate(time.Date(2023, time.January, 14, 9, 30, 0, 0, time.UTC)), bread.Date},
+		{"Images", 1, len(bread.Images)},
+
+		{"No nutrition", "", recipes[1].Nutrition},
+		{"No images", 0, len(recipes[1].Images)},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.want, c.got) {
+			t.Errorf("Incorrect %s: want = %#v, got = %#v", c.field, c.want, c.got)
+		}
+	}
+}
+
+func TestRecipe_SaveNextcloud_RoundTrip(t *testing.T) {
+	want := exportRecipe(t)
+
+	dir := t.TempDir()
+	folder, err := want.SaveNextcloud(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := mela.ParseNextcloudRecipe(os.DirFS(folder), ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.ID != want.ID || got.Title != want.Title || got.Text != want.Text || got.Ingredients != want.Ingredients ||
+		got.Instructions != want.Instructions || got.Nutrition != want.Nutrition || got.Yield != want.Yield ||
+		got.PrepTime != want.PrepTime || got.CookTime != want.CookTime || got.TotalTime != want.TotalTime ||
+		got.Date != want.Date || !reflect.DeepEqual(got.Categories, want.Categories) {
+		t.Errorf("Recipe changed in round trip: want = %#v, got = %#v", want, got)
+	}
+	if len(got.Images) != 1 || !bytes.Equal(got.Images[0], want.Images[0]) {
+		t.Error("Image changed in round trip")
+	}
+}
@@ -0,0 +1,285 @@
+package mela
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// paprikaRecipe is the JSON structure of a recipe exported from Paprika Recipe Manager.
+type paprikaRecipe struct {
+	UID             string         `json:"uid"`
+	Name            string         `json:"name"`
+	Description     string         `json:"description"`
+	Ingredients     string         `json:"ingredients"`
+	Directions      string         `json:"directions"`
+	Notes           string         `json:"notes"`
+	NutritionalInfo string         `json:"nutritional_info"`
+	Servings        string         `json:"servings"`
+	PrepTime        string         `json:"prep_time"`
+	CookTime        string         `json:"cook_time"`
+	TotalTime       string         `json:"total_time"`
+	Difficulty      string         `json:"difficulty"`
+	Rating          int            `json:"rating"`
+	Categories      []string       `json:"categories"`
+	Source          string         `json:"source"`
+	SourceURL       string         `json:"source_url"`
+	ImageURL        string         `json:"image_url"`
+	Photo           string         `json:"photo"`
+	PhotoHash       string         `json:"photo_hash"`
+	PhotoData       string         `json:"photo_data"`
+	Photos          []paprikaPhoto `json:"photos"`
+	Created         string         `json:"created"`
+	Hash            string         `json:"hash"`
+}
+
+type paprikaPhoto struct {
+	Name     string `json:"name"`
+	Filename string `json:"filename"`
+	Hash     string `json:"hash"`
+	Data     string `json:"data"`
+}
+
+const paprikaTimeFormat = "2006-01-02 15:04:05"
+
+// paprikaRatingLine is how Paprika ratings below favoriteRating, which Mela has no field for, are kept in a recipe's
+// notes. They're turned back into ratings when exported to Paprika.
+var paprikaRatingLine = regexp.MustCompile(`(?m)^Rating: ([1-4])/5\n?`)
+
+// ParsePaprikaRecipe parses a single (gzipped JSON) .paprikarecipe file into a Recipe.
+func ParsePaprikaRecipe(r io.Reader) (*Recipe, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var pr paprikaRecipe
+	if err := json.NewDecoder(gz).Decode(&pr); err != nil {
+		return nil, err
+	}
+
+	return pr.toRecipe()
+}
+
+// ParsePaprikaRecipes parses a .paprikarecipes collection file into a stream of Recipes, calling the onRecipe func for
+// each, as it is parsed.
+func ParsePaprikaRecipes(r io.ReaderAt, size int64, onRecipe func(*Recipe, error)) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	for _, zf := range zr.File {
+		rr, err := zf.Open()
+		if err != nil {
+			onRecipe(nil, err)
+			continue
+		}
+
+		recipe, err := ParsePaprikaRecipe(rr)
+		rr.Close()
+		if err != nil {
+			onRecipe(nil, fmt.Errorf("unable to parse '%s': %w", zf.Name, err))
+			continue
+		}
+
+		recipe.Filename = withoutExt(zf.Name)
+		onRecipe(recipe, nil)
+	}
+
+	return nil
+}
+
+func (pr paprikaRecipe) toRecipe() (*Recipe, error) {
+	r := &Recipe{
+		ID:           pr.UID,
+		Title:        pr.Name,
+		Link:         pr.SourceURL,
+		Text:         pr.Description,
+		Ingredients:  SectionedSequence(pr.Ingredients),
+		Instructions: SectionedSequence(pr.Directions),
+		Nutrition:    pr.NutritionalInfo,
+		Categories:   pr.Categories,
+		Notes:        pr.Notes,
+		Images:       make([]B64Image, 0),
+		Yield:        PeopleCount(pr.Servings),
+		PrepTime:     MaybeDuration(pr.PrepTime),
+		CookTime:     MaybeDuration(pr.CookTime),
+		TotalTime:    MaybeDuration(pr.TotalTime),
+		Favorite:     pr.Rating >= favoriteRating,
+	}
+
+	if r.Link == "" {
+		r.Link = pr.Source
+	}
+	if pr.Rating > 0 && pr.Rating < favoriteRating {
+		if r.Notes != "" {
+			r.Notes += "\n"
+		}
+		r.Notes += fmt.Sprintf("Rating: %d/5", pr.Rating)
+	}
+	if r.Categories == nil {
+		r.Categories = make([]string, 0)
+	}
+
+	if pr.Created != "" {
+		created, err := time.Parse(paprikaTimeFormat, pr.Created)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created date '%s': %w", pr.Created, err)
+		}
+		r.Date = NewAppleDate(created)
+	}
+
+	photos := []string{pr.PhotoData}
+	for _, p := range pr.Photos {
+		photos = append(photos, p.Data)
+	}
+	for _, data := range photos {
+		if data == "" {
+			continue
+		}
+		img, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid photo data: %w", err)
+		}
+		r.Images = append(r.Images, img)
+	}
+
+	return r, nil
+}
+
+func paprikaFromRecipe(r *Recipe) (*paprikaRecipe, error) {
+	pr := &paprikaRecipe{
+		UID:             r.ID,
+		Name:            r.Title,
+		Description:     r.Text,
+		Ingredients:     string(r.Ingredients),
+		Directions:      string(r.Instructions),
+		Notes:           r.Notes,
+		NutritionalInfo: r.Nutrition,
+		Servings:        string(r.Yield),
+		PrepTime:        string(r.PrepTime),
+		CookTime:        string(r.CookTime),
+		TotalTime:       string(r.TotalTime),
+		Categories:      r.Categories,
+		Photos:          make([]paprikaPhoto, 0),
+	}
+
+	if pr.Categories == nil {
+		pr.Categories = make([]string, 0)
+	}
+	if m := paprikaRatingLine.FindStringSubmatch(r.Notes); m != nil {
+		pr.Rating, _ = strconv.Atoi(m[1])
+		pr.Notes = strings.TrimSpace(paprikaRatingLine.ReplaceAllString(r.Notes, ""))
+	}
+	if r.Favorite {
+		pr.Rating = favoriteRating
+	}
+	if r.Date != 0 {
+		pr.Created = r.Date.Time().Format(paprikaTimeFormat)
+	}
+
+	if u, err := url.Parse(r.Link); err == nil && u.Host != "" {
+		pr.SourceURL = r.Link
+		pr.Source = u.Host
+	} else {
+		pr.Source = r.Link
+	}
+
+	for i, img := range r.Images {
+		sum := sha256.Sum256(img)
+		hash := hex.EncodeToString(sum[:])
+		data := base64.StdEncoding.EncodeToString(img)
+
+		if i == 0 {
+			pr.Photo = hash + ".jpg"
+			pr.PhotoHash = hash
+			pr.PhotoData = data
+			continue
+		}
+		pr.Photos = append(pr.Photos, paprikaPhoto{
+			Name:     fmt.Sprintf("%d", i),
+			Filename: hash + ".jpg",
+			Hash:     hash,
+			Data:     data,
+		})
+	}
+
+	data, err := json.Marshal(pr)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	pr.Hash = hex.EncodeToString(sum[:])
+
+	return pr, nil
+}
+
+// WritePaprika writes the recipe as a single (gzipped JSON) .paprikarecipe file.
+func (r *Recipe) WritePaprika(w io.Writer) error {
+	pr, err := paprikaFromRecipe(r)
+	if err != nil {
+		return fmt.Errorf("unable to convert recipe: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(pr); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// PaprikaRecipes is a .paprikarecipes collection file being written.
+type PaprikaRecipes struct {
+	f   *os.File
+	zip *zip.Writer
+}
+
+// NewPaprikaBundle creates a .paprikarecipes (zip file) and allows writing new recipes directly to it with .Add().
+func NewPaprikaBundle(dir, name string) (*PaprikaRecipes, error) {
+	filename := path.Join(dir, stringToFilename(name)+".paprikarecipes")
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaprikaRecipes{
+		f:   f,
+		zip: zip.NewWriter(f),
+	}, nil
+}
+
+func (ps *PaprikaRecipes) Close() error {
+	if err := ps.zip.Close(); err != nil {
+		return err
+	}
+	return ps.f.Close()
+}
+
+func (ps *PaprikaRecipes) Add(r *Recipe) error {
+	filename := r.Filename
+	if filename == "" {
+		filename = stringToFilename(r.Title)
+	}
+
+	// The entries are already gzipped, so aren't compressed again
+	w, err := ps.zip.CreateHeader(&zip.FileHeader{Name: filename + ".paprikarecipe", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+
+	return r.WritePaprika(w)
+}
@@ -0,0 +1,122 @@
+package mela_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+// openExportFixture parses every recipe in an archive file with the given parser, as exported by another recipe app.
+func openExportFixture(t *testing.T, parse func(io.ReaderAt, int64, func(*mela.Recipe, error)) error, filename string) []*mela.Recipe {
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	fs, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var recipes []*mela.Recipe
+	err = parse(f, fs.Size(), func(r *mela.Recipe, err error) {
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		recipes = append(recipes, r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return recipes
+}
+
+func TestParsePaprikaRecipes(t *testing.T) {
+	recipes := openExportFixture(t, mela.ParsePaprikaRecipes, "fixtures/a+b.paprikarecipes")
+	if len(recipes) != 2 {
+		t.Fatalf("Incorrect number of recipes: want = 2, got = %d", len(recipes))
+	}
+
+	chicken := recipes[0]
+	wantDate := mela.NewAppleDate(time.Date(2021, time.March, 4, 5, 6, 7, 0, time.UTC))
+	checks := []struct {
+		field     string
+		want, got any
+	}{
+		{"Filename", "Paprika chicken", chicken.Filename},
+		{"ID", "F1E5A7D2-3C4B-4E8F-9A1B-2C3D4E5F6A7B", chicken.ID},
+		{"Link", "https://example.com/paprika-chicken", chicken.Link},
+		{"Yield", mela.PeopleCount("4"), chicken.Yield},
+		{"TotalTime", mela.MaybeDuration("50 mins"), chicken.TotalTime},
+		{"Categories", []string{"Dinner", "Chicken"}, chicken.Categories},
+		{"Notes", "Good with rice.", chicken.Notes},
+		{"Nutrition", "Calories: 400", chicken.Nutrition},
+		{"Favorite", true, chicken.Favorite},
+		{"Date", wantDate, chicken.Date},
+		{"Images", 2, len(chicken.Images)},
+
+		{"Link without URL", "Grandma", recipes[1].Link},
+		{"Not favorite", false, recipes[1].Favorite},
+		{"Rating in notes", "Rating: 2/5", recipes[1].Notes},
+		{"No images", 0, len(recipes[1].Images)},
+	}
+
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.want, c.got) {
+			t.Errorf("Incorrect %s: want = %#v, got = %#v", c.field, c.want, c.got)
+		}
+	}
+}
+
+func TestPaprikaBundle_RoundTrip(t *testing.T) {
+	recipes, err := mela.Open("fixtures/a+b.melarecipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipes[0].Favorite = true
+	recipes[1].Notes = "Rating: 3/5"
+
+	dir := t.TempDir()
+	bundle, err := mela.NewPaprikaBundle(dir, "Round Trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range recipes {
+		if err := bundle.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := bundle.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := openExportFixture(t, mela.ParsePaprikaRecipes, filepath.Join(dir, "round-trip.paprikarecipes"))
+	if len(got) != len(recipes) {
+		t.Fatalf("Incorrect number of recipes: want = %d, got = %d", len(recipes), len(got))
+	}
+
+	for i, want := range recipes {
+		g := got[i]
+		if g.ID != want.ID || g.Title != want.Title || g.Link != want.Link || g.Ingredients != want.Ingredients ||
+			g.Instructions != want.Instructions || g.Notes != want.Notes || g.Yield != want.Yield ||
+			g.PrepTime != want.PrepTime || g.CookTime != want.CookTime || g.Favorite != want.Favorite ||
+			!reflect.DeepEqual(g.Categories, want.Categories) {
+			t.Errorf("Recipe %s changed in round trip: want = %#v, got = %#v", want.ID, want, g)
+		}
+		if !want.Date.Time().Truncate(time.Second).Equal(g.Date.Time()) {
+			t.Errorf("Incorrect date for %s: want = %v, got = %v", want.ID, want.Date.Time(), g.Date.Time())
+		}
+		if len(g.Images) != 1 || !bytes.Equal(g.Images[0], want.Images[0]) {
+			t.Errorf("Images changed in round trip for %s", want.ID)
+		}
+	}
+}
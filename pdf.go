@@ -0,0 +1,252 @@
+package mela
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+)
+
+// pdfFont is one of the standard PDF fonts, which every reader has so they needn't be embedded.
+type pdfFont int
+
+const (
+	pdfRegular pdfFont = iota
+	pdfBold
+)
+
+var pdfFontNames = []string{"Helvetica", "Helvetica-Bold"}
+
+// pdfWidths are the widths of the printable ASCII characters (from space) in thousandths of the font size.
+var pdfWidths = [][]int{
+	{278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584},
+	{278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584},
+}
+
+// pdfSpecialWidths are the widths of the non-ASCII characters common in recipes.
+var pdfSpecialWidths = map[rune]int{
+	'½': 834, '¼': 834, '¾': 834, '°': 400, '–': 556, '—': 1000, '‘': 222, '’': 222, '“': 333, '”': 333,
+	'•': 350, '×': 584, '…': 1000, '·': 278, '€': 556, '£': 556,
+}
+
+// pdfTextWidth measures text in points.
+func pdfTextWidth(text string, font pdfFont, size float64) float64 {
+	total := 0
+	for _, r := range text {
+		total += pdfRuneWidth(r, font)
+	}
+	return float64(total) * size / 1000
+}
+
+func pdfRuneWidth(r rune, font pdfFont) int {
+	if r >= ' ' && r <= '~' {
+		return pdfWidths[font][r-' ']
+	}
+	if w, ok := pdfSpecialWidths[r]; ok {
+		return w
+	}
+	// Accented letters are about as wide as the letter itself
+	if base, _, err := transform.String(removeAccents, string(r)); err == nil && len(base) == 1 && base[0] >= ' ' && base[0] <= '~' {
+		return pdfWidths[font][base[0]-' ']
+	}
+	return 556
+}
+
+// pdfWrap splits text into lines no wider than width, breaking between words where possible.
+func pdfWrap(text string, font pdfFont, size, width float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if pdfTextWidth(candidate, font, size) <= width {
+			line = candidate
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+		// Words too long for a line of their own are broken wherever they reach the edge
+		for pdfTextWidth(word, font, size) > width {
+			runes := []rune(word)
+			n := len(runes) - 1
+			for n > 1 && pdfTextWidth(string(runes[:n]), font, size) > width {
+				n--
+			}
+			lines = append(lines, string(runes[:n]))
+			word = string(runes[n:])
+		}
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// pdfImage is a JPEG image to be placed in a PDF.
+type pdfImage struct {
+	data          []byte
+	width, height int
+	colorSpace    string
+}
+
+// newPDFImage prepares an image for a PDF, converting it to JPEG if it isn't one already.
+func newPDFImage(img B64Image) (*pdfImage, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(img))
+	if err != nil {
+		return nil, err
+	}
+
+	if format != "jpeg" || cfg.ColorModel == color.CMYKModel {
+		decoded, _, err := image.Decode(bytes.NewReader(img))
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, decoded, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, err
+		}
+		return newPDFImage(buf.Bytes())
+	}
+
+	colorSpace := "DeviceRGB"
+	if cfg.ColorModel == color.GrayModel {
+		colorSpace = "DeviceGray"
+	}
+	return &pdfImage{data: img, width: cfg.Width, height: cfg.Height, colorSpace: colorSpace}, nil
+}
+
+// pdfPage is the content of a single page of a PDF, in PDF's coordinates (points, from the bottom left).
+type pdfPage struct {
+	content bytes.Buffer
+	images  []*pdfImage
+}
+
+func (p *pdfPage) text(x, y float64, text string, font pdfFont, size, gray float64) {
+	fmt.Fprintf(&p.content, "BT %.3f g /F%d %.2f Tf %.2f %.2f Td (%s) Tj ET\n", gray, font+1, size, x, y, pdfString(text))
+}
+
+func (p *pdfPage) image(img *pdfImage, x, y, w, h float64) {
+	p.images = append(p.images, img)
+	fmt.Fprintf(&p.content, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", w, h, x, y, len(p.images))
+}
+
+func (p *pdfPage) line(x1, y1, x2, y2, gray float64) {
+	fmt.Fprintf(&p.content, "%.3f G 0.5 w %.2f %.2f m %.2f %.2f l S\n", gray, x1, y1, x2, y2)
+}
+
+var pdfStringEscaper = strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", `\r`, "\n", `\n`)
+
+// pdfString encodes text for the standard fonts, replacing any characters they don't have.
+func pdfString(text string) string {
+	var b strings.Builder
+	enc := charmap.Windows1252.NewEncoder()
+	for _, r := range text {
+		if encoded, err := enc.String(string(r)); err == nil {
+			b.WriteString(encoded)
+		} else if base, _, err := transform.String(removeAccents, string(r)); err == nil && base != string(r) {
+			b.WriteString(pdfString(base))
+		} else {
+			b.WriteByte('?')
+		}
+	}
+	return pdfStringEscaper.Replace(b.String())
+}
+
+// writePDF writes the pages as a PDF document of the given page size.
+func writePDF(w io.Writer, pages []*pdfPage, width, height float64, title string) error {
+	var objects [][]byte
+	add := func(obj string) int {
+		objects = append(objects, []byte(obj))
+		return len(objects)
+	}
+	addStream := func(dict string, data []byte) int {
+		var b bytes.Buffer
+		fmt.Fprintf(&b, "<< %s /Length %d >>\nstream\n", dict, len(data))
+		b.Write(data)
+		b.WriteString("\nendstream")
+		objects = append(objects, b.Bytes())
+		return len(objects)
+	}
+
+	catalog := add("") // filled in once the page tree is known
+	info := add(fmt.Sprintf("<< /Title (%s) /Producer (mela-recipes) >>", pdfString(title)))
+	var fonts []string
+	for i, name := range pdfFontNames {
+		id := add(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+		fonts = append(fonts, fmt.Sprintf("/F%d %d 0 R", i+1, id))
+	}
+	pageTree := add("")
+
+	imageIDs := make(map[*pdfImage]int)
+	var kids []string
+	for _, p := range pages {
+		var xobjects []string
+		for i, img := range p.images {
+			id, ok := imageIDs[img]
+			if !ok {
+				id = addStream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /DCTDecode",
+					img.width, img.height, img.colorSpace), img.data)
+				imageIDs[img] = id
+			}
+			xobjects = append(xobjects, fmt.Sprintf("/Im%d %d 0 R", i+1, id))
+		}
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(p.content.Bytes()); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		content := addStream("/Filter /FlateDecode", compressed.Bytes())
+		page := add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Contents %d 0 R /Resources << /Font << %s >> /XObject << %s >> >> >>",
+			pageTree, width, height, content, strings.Join(fonts, " "), strings.Join(xobjects, " ")))
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+
+	objects[catalog-1] = []byte(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pageTree))
+	objects[pageTree-1] = []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(obj)
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, catalog, info, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
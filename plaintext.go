@@ -0,0 +1,366 @@
+package mela
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var ErrEmptyText = errors.New("the given text has no recipe in it")
+
+var textHeading = regexp.MustCompile(`(?i)^[#*_\s]*(ingredients?|what you(?:'ll| will) need|you(?:'ll| will) need|` +
+	`method|directions?|instructions?|preparation|steps|how to make(?: it)?|` +
+	`notes?|tips?|cook'?s notes?|variations?)\s*(?:\([^)]*\))?\s*[:*_]*\s*$`)
+var textTitleLabel = regexp.MustCompile(`(?i)^(?:recipe|title)\s*:\s*`)
+var textYield = regexp.MustCompile(`(?i)^(?:serves|servings|yields?|portions|feeds|makes)\s*:?\s*(.+)$`)
+var textTime = regexp.MustCompile(`(?i)^(prep(?:aration)?|cook(?:ing)?|bak(?:e|ing)|total|ready in)(\s+time)?\s*(:)?\s*(.+)$`)
+var textSource = regexp.MustCompile(`(?i)^(?:source|from|adapted from|recipe from|via)\s*:\s*(.+)$`)
+var textURL = regexp.MustCompile(`^https?://\S+$`)
+var textMetaSplitter = regexp.MustCompile(`\s*(?:\||•|·|;)\s*`)
+var textBullet = regexp.MustCompile(`^\s*(?:[-*•·–]|\[[ x]?\])\s+`)
+var textStepHeading = regexp.MustCompile(`(?i)^step\s*\d+\s*:?$`)
+var textSubheading = regexp.MustCompile(`^#+\s*(.+?)\s*$|^(.{1,40}):$`)
+
+type textSection int
+
+const (
+	textPreamble textSection = iota
+	textIngredients
+	textInstructions
+	textNotes
+)
+
+var textSections = map[string]textSection{
+	"ingredient": textIngredients, "ingredients": textIngredients, "what you'll need": textIngredients,
+	"what you will need": textIngredients, "you'll need": textIngredients, "you will need": textIngredients,
+	"method": textInstructions, "direction": textInstructions, "directions": textInstructions,
+	"instruction": textInstructions, "instructions": textInstructions, "preparation": textInstructions,
+	"steps": textInstructions, "how to make": textInstructions, "how to make it": textInstructions,
+	"note": textNotes, "notes": textNotes, "tip": textNotes, "tips": textNotes, "cook's notes": textNotes,
+	"cooks notes": textNotes, "cook's note": textNotes, "cooks note": textNotes, "variation": textNotes,
+	"variations": textNotes,
+}
+
+// TextConfidence records how sure ParseText is of each field it filled in, from 0 (a guess) to 1 (certain), keyed by
+// the field's JSON name, eg. "title" or "prepTime". Fields that weren't found are absent.
+type TextConfidence map[string]float64
+
+// Uncertain lists the fields that were filled in with a confidence below threshold, in alphabetical order.
+func (c TextConfidence) Uncertain(threshold float64) []string {
+	var fields []string
+	for field, conf := range c {
+		if conf < threshold {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// ParseText makes a recipe from unstructured text, like a recipe pasted from an email. It looks for a title, labelled
+// ingredients, method and notes blocks (or, without labels, a run of ingredient-like lines), and serving, time and
+// source lines. How sure it is of each field is returned alongside, so uncertain fields can be checked by hand.
+func ParseText(r io.Reader) (*Recipe, TextConfidence, error) {
+	recipe := &Recipe{Categories: make([]string, 0), Images: make([]B64Image, 0)}
+	conf := make(TextConfidence)
+
+	blocks := make(map[textSection][]string)
+	labelled := make(map[textSection]bool)
+	section := textPreamble
+	empty := true
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.ReplaceAll(scanner.Text(), "\u00a0", " "))
+		if line != "" {
+			empty = false
+		}
+
+		if m := textHeading.FindStringSubmatch(line); m != nil {
+			section = textSections[strings.ToLower(strings.ReplaceAll(m[1], "’", "'"))]
+			labelled[section] = true
+			continue
+		}
+		if section != textNotes && applyTextMeta(recipe, conf, line) {
+			continue
+		}
+		blocks[section] = append(blocks[section], line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if empty {
+		return nil, nil, ErrEmptyText
+	}
+
+	preamble := textParagraphs(blocks[textPreamble])
+	if len(preamble) > 0 {
+		title, titleConf := textTitle(preamble[0][0], len(preamble[0]) == 1)
+		recipe.Title, conf["title"] = title, titleConf
+		if preamble[0] = preamble[0][1:]; len(preamble[0]) == 0 {
+			preamble = preamble[1:]
+		}
+	}
+
+	ingredients := textParagraphs(blocks[textIngredients])
+	instructions := textParagraphs(blocks[textInstructions])
+	ingConf, instConf := 0.7, 0.9
+
+	if !labelled[textIngredients] {
+		// Without labels, ingredients are the first paragraph that mostly looks like them
+		ingConf, instConf = 0.3, 0.5
+		for i, para := range preamble {
+			if textIngredientShare(para) >= 0.6 {
+				ingredients = preamble[i : i+1]
+				if !labelled[textInstructions] {
+					instructions = append(preamble[i+1:], instructions...)
+				}
+				preamble = preamble[:i]
+				break
+			}
+		}
+	} else if !labelled[textInstructions] {
+		// The method often follows the ingredients without a label of its own
+		instConf = 0.6
+		for i, para := range ingredients {
+			if i > 0 && textIngredientShare(para) < 0.5 {
+				instructions = append(ingredients[i:], instructions...)
+				ingredients = ingredients[:i]
+				break
+			}
+		}
+	}
+
+	if len(preamble) > 0 {
+		var text []string
+		for _, para := range preamble {
+			text = append(text, strings.Join(para, " "))
+		}
+		recipe.Text, conf["text"] = strings.Join(text, "\n"), 0.6
+	}
+
+	if lines := textIngredientLines(ingredients); len(lines) > 0 {
+		recipe.Ingredients = SectionedSequence(strings.Join(lines, "\n"))
+		var all []string
+		for _, para := range ingredients {
+			all = append(all, para...)
+		}
+		conf["ingredients"] = ingConf + 0.25*textIngredientShare(all)
+	}
+
+	if steps := textSteps(instructions); len(steps) > 0 {
+		recipe.Instructions = SectionedSequence(strings.Join(steps, "\n"))
+		conf["instructions"] = instConf
+	}
+
+	var notes []string
+	for _, para := range textParagraphs(blocks[textNotes]) {
+		notes = append(notes, strings.Join(para, " "))
+	}
+	if len(notes) > 0 {
+		recipe.Notes, conf["notes"] = strings.Join(notes, "\n"), 0.9
+	}
+
+	return recipe, conf, nil
+}
+
+// applyTextMeta fills in the yield, times or link from lines like "Serves 4 | Prep: 10 mins", reporting whether the
+// line was entirely made of such details.
+func applyTextMeta(r *Recipe, conf TextConfidence, line string) bool {
+	if line == "" {
+		return false
+	}
+	if textURL.MatchString(line) {
+		if r.Link == "" {
+			r.Link, conf["link"] = line, 0.7
+		}
+		return true
+	}
+	if m := textSource.FindStringSubmatch(line); m != nil {
+		r.Link, conf["link"] = strings.TrimSpace(m[1]), 0.8
+		return true
+	}
+
+	type detail struct {
+		field, value string
+		conf         float64
+	}
+	var details []detail
+	for _, part := range textMetaSplitter.Split(line, -1) {
+		if m := textYield.FindStringSubmatch(part); m != nil {
+			if _, err := ParseYield(m[1]); err != nil {
+				return false
+			}
+			details = append(details, detail{"yield", m[1], 0.9})
+			continue
+		}
+
+		m := textTime.FindStringSubmatch(part)
+		if m == nil || (m[2] == "" && m[3] == "" && !strings.EqualFold(m[1], "ready in")) {
+			return false
+		}
+		field := "cookTime"
+		switch strings.ToLower(m[1])[:3] {
+		case "pre":
+			field = "prepTime"
+		case "tot", "rea":
+			field = "totalTime"
+		}
+		value, c := strings.TrimSpace(m[4]), 0.5
+		if d, err := MaybeDuration(value).Parse(); err == nil && d != nil {
+			value, c = string(FormatDuration(*d, DurationStyleLong)), 0.9
+		}
+		details = append(details, detail{field, value, c})
+	}
+
+	for _, d := range details {
+		switch d.field {
+		case "yield":
+			r.Yield = PeopleCount(d.value)
+		case "prepTime":
+			r.PrepTime = MaybeDuration(d.value)
+		case "cookTime":
+			r.CookTime = MaybeDuration(d.value)
+		case "totalTime":
+			r.TotalTime = MaybeDuration(d.value)
+		}
+		conf[d.field] = d.conf
+	}
+	return len(details) > 0
+}
+
+// textTitle cleans up a title line, and judges how title-like it is.
+func textTitle(line string, alone bool) (string, float64) {
+	if m := textTitleLabel.FindString(line); m != "" {
+		return strings.TrimSpace(line[len(m):]), 0.95
+	}
+	title := strings.TrimSpace(strings.Trim(line, "#*_ "))
+	if title == "" {
+		return line, 0.1
+	}
+
+	c := 0.5
+	if len(title) <= 60 && len(strings.Fields(title)) <= 10 {
+		c += 0.2
+	}
+	if !strings.ContainsAny(title[len(title)-1:], ".!?:,") {
+		c += 0.1
+	}
+	if alone {
+		c += 0.1
+	}
+	return title, c
+}
+
+// textParagraphs splits lines into runs of non-blank lines.
+func textParagraphs(lines []string) [][]string {
+	var paras [][]string
+	var para []string
+	for _, line := range lines {
+		if line == "" {
+			if len(para) > 0 {
+				paras = append(paras, para)
+				para = nil
+			}
+			continue
+		}
+		para = append(para, line)
+	}
+	if len(para) > 0 {
+		paras = append(paras, para)
+	}
+	return paras
+}
+
+// textIngredientShare is the proportion of lines that look like ingredients: short, and bulleted or starting with an
+// amount.
+func textIngredientShare(lines []string) float64 {
+	if len(lines) == 0 {
+		return 0
+	}
+	n := 0
+	for _, line := range lines {
+		if textLooksLikeIngredient(line) {
+			n++
+		}
+	}
+	return float64(n) / float64(len(lines))
+}
+
+func textLooksLikeIngredient(line string) bool {
+	if len(line) > 80 || strings.HasSuffix(line, ".") && strings.Count(line, " ") > 6 {
+		return false
+	}
+	if textBullet.MatchString(line) {
+		return true
+	}
+	ing := ParseIngredient(line)
+	return ing.Quantity > 0 || ing.Unit != ""
+}
+
+// textIngredientLines removes bullets from ingredient lines, and turns lines like "For the sauce:" into headings.
+func textIngredientLines(paras [][]string) []string {
+	var lines []string
+	for _, para := range paras {
+		for _, line := range para {
+			if heading := textSubheadingText(line); heading != "" && !textLooksLikeIngredient(line) {
+				lines = append(lines, "# "+heading)
+				continue
+			}
+			lines = append(lines, textBullet.ReplaceAllString(line, ""))
+		}
+	}
+	return lines
+}
+
+func textSubheadingText(line string) string {
+	m := textSubheading.FindStringSubmatch(line)
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(m[1] + m[2])
+}
+
+// textSteps splits the method into steps: numbered or bulleted items, paragraphs, or lines that start a new sentence.
+// Lines that carry on the previous sentence (eg. from a hard-wrapped email) are joined back together.
+func textSteps(paras [][]string) []string {
+	var steps []string
+	for _, para := range paras {
+		continuing := false
+		for _, line := range para {
+			if textStepHeading.MatchString(line) {
+				continuing = false
+				continue
+			}
+			if heading := textSubheadingText(line); heading != "" && !stepPrefix.MatchString(line) {
+				steps = append(steps, "# "+heading)
+				continuing = false
+				continue
+			}
+
+			numbered := stepPrefix.MatchString(line) || textBullet.MatchString(line)
+			text := textBullet.ReplaceAllString(stepPrefix.ReplaceAllString(line, ""), "")
+			if continuing && !numbered && textContinues(steps[len(steps)-1], text) {
+				steps[len(steps)-1] += " " + text
+				continue
+			}
+			steps = append(steps, text)
+			continuing = true
+		}
+	}
+	return steps
+}
+
+// textContinues reports whether next carries on from prev, rather than starting a new step.
+func textContinues(prev, next string) bool {
+	if next == "" {
+		return false
+	}
+	// Hard-wrapped lines are long, and stop mid-sentence
+	wrapped := len(prev) >= 60 && !strings.ContainsAny(prev[len(prev)-1:], ".!?)")
+	return unicode.IsLower([]rune(next)[0]) || wrapped
+}
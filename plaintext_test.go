@@ -0,0 +1,78 @@
+package mela_test
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func TestParseText(t *testing.T) {
+	tests := []struct {
+		file      string
+		want      *mela.Recipe
+		uncertain []string
+	}{
+		{
+			file: "fixtures/text/labelled.txt",
+			want: &mela.Recipe{
+				Title:      "Lemon Drizzle Cake",
+				Text:       "A sharp, sticky loaf cake that keeps well for a few days.",
+				Link:       "https://example.com/lemon-drizzle",
+				Yield:      "8",
+				PrepTime:   "15 mins",
+				CookTime:   "45 mins",
+				Categories: []string{},
+				Images:     []mela.B64Image{},
+				Ingredients: "225g unsalted butter, softened\n225g caster sugar\n4 eggs\n225g self-raising flour\n" +
+					"1 lemon, zested\n# For the drizzle\n85g caster sugar\nJuice of 1 lemon",
+				Instructions: "Heat the oven to 180C. Beat the butter and sugar together until pale and creamy, then add the eggs one at a time.\n" +
+					"Fold in the flour and lemon zest, then tip into a lined loaf tin and bake for 45 minutes.\n" +
+					"Mix the lemon juice and sugar, and pour over the cake while it's still warm.",
+				Notes: "Freezes well, without the drizzle.",
+			},
+			uncertain: []string{"text"},
+		},
+		{
+			file: "fixtures/text/unlabelled.txt",
+			want: &mela.Recipe{
+				Title:       "Quick tomato sauce",
+				Categories:  []string{},
+				Images:      []mela.B64Image{},
+				Ingredients: "2 tbsp olive oil\n2 garlic cloves, crushed\n400g tin chopped tomatoes\nPinch of sugar",
+				Instructions: "Warm the oil and fry the garlic until it just starts to colour.\n" +
+					"Add the tomatoes and sugar, and simmer for 20 minutes until thick.\nSeason to taste.",
+			},
+			uncertain: []string{"ingredients", "instructions"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			f, err := os.Open(test.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			recipe, conf, err := mela.ParseText(f)
+			if err != nil {
+				t.Fatalf("Unable to parse text: %v", err)
+			}
+			if !reflect.DeepEqual(test.want, recipe) {
+				t.Errorf("Incorrect recipe:\nwant = %#v\ngot  = %#v", test.want, recipe)
+			}
+			if uncertain := conf.Uncertain(0.7); !reflect.DeepEqual(test.uncertain, uncertain) {
+				t.Errorf("Incorrect uncertain fields: want = %v, got = %v (%v)", test.uncertain, uncertain, conf)
+			}
+		})
+	}
+}
+
+func TestParseText_Empty(t *testing.T) {
+	if _, _, err := mela.ParseText(strings.NewReader("\n  \n")); err != mela.ErrEmptyText {
+		t.Errorf("Expected ErrEmptyText, got %v", err)
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -29,7 +31,12 @@ type Recipe struct {
 	CookTime  MaybeDuration `json:"cookTime"`
 	TotalTime MaybeDuration `json:"totalTime"`
 
+	Favorite   bool      `json:"favorite"`
+	WantToCook bool      `json:"wantToCook"`
+	Date       AppleDate `json:"date,omitempty"`
+
 	standardizationsMade []string
+	warnings             []string
 }
 
 var ErrInvalidMelaFile = errors.New("given file is neither a melarecipe nor a melarecipes file")
@@ -104,27 +111,45 @@ func sourceName(linkField string) string {
 	return kebabCaser.ReplaceAllString(strings.ToLower(linkField), "-")
 }
 
+// Save writes the recipe as a .melarecipe file, in a folder (within dir) named after its source, and returns its path.
 func (r *Recipe) Save(dir string) (string, error) {
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		return "", fmt.Errorf("output directory '%s' does not exist", dir)
 	}
 
+	destination, err := r.SaveFS(DirFS(dir), ".")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.FromSlash(destination)), nil
+}
+
+// SaveFS writes the recipe as Save does, but to the directory dir of fsys, returning its path within fsys.
+func (r *Recipe) SaveFS(fsys WriteFS, dir string) (string, error) {
+	if info, err := fs.Stat(fsys, dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("output directory '%s' does not exist", dir)
+	}
+
 	data, err := json.Marshal(r)
 	if err != nil {
 		return "", fmt.Errorf("unable to marshal recipe: %w", err)
 	}
 
-	destination := filepath.Join(dir, sourceName(r.Link), r.Filename+".melarecipe")
-	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
-		return "", fmt.Errorf("unable to create recipe directory '%s': %w", filepath.Dir(destination), err)
+	destination := path.Join(dir, sourceName(r.Link), r.Filename+".melarecipe")
+	if err := fsys.MkdirAll(path.Dir(destination), 0755); err != nil {
+		return "", fmt.Errorf("unable to create recipe directory '%s': %w", path.Dir(destination), err)
 	}
 
-	f, err := os.Create(destination)
+	f, err := fsys.OpenFile(destination, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
 	if err != nil {
 		return "", fmt.Errorf("unable to create recipe file: %w", err)
 	}
 
 	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", fmt.Errorf("unable to write data to recipe file: %w", err)
+	}
+	if err := f.Close(); err != nil {
 		return "", fmt.Errorf("unable to write data to recipe file: %w", err)
 	}
 
@@ -69,6 +69,9 @@ var twoMin = 2 * time.Minute
 var twoHour = 2 * time.Hour
 var thirtyMin = 30 * time.Minute
 var threeHour = 3 * time.Hour
+var oneHourOneMin = time.Hour + time.Minute
+var twoHourTwoMin = 2*time.Hour + 2*time.Minute
+var threeHourThirtyMin = 3*time.Hour + 30*time.Minute
 
 var wantFixtures = map[string]struct {
 	ID         string
@@ -99,6 +102,7 @@ var wantFixtures = map[string]struct {
 		ParsedIngredients:  map[string][]string{"": {"A ingredients"}},
 		ParsedPrepTime:     &oneMin,
 		ParsedCookTime:     &oneHour,
+		ParsedTotalTime:    &oneHourOneMin,
 		ParsedInstructions: map[string][]string{"": {"A instructions"}},
 	},
 	"b": {
@@ -114,6 +118,7 @@ var wantFixtures = map[string]struct {
 		ParsedIngredients:  map[string][]string{"": {"B ingredients"}},
 		ParsedPrepTime:     &twoMin,
 		ParsedCookTime:     &twoHour,
+		ParsedTotalTime:    &twoHourTwoMin,
 		ParsedInstructions: map[string][]string{"": {"B instructions"}},
 	},
 	"c": {
@@ -129,6 +134,7 @@ var wantFixtures = map[string]struct {
 		ParsedIngredients:  map[string][]string{"": {"C ingredients"}},
 		ParsedPrepTime:     &threeHour,
 		ParsedCookTime:     &thirtyMin,
+		ParsedTotalTime:    &threeHourThirtyMin,
 		ParsedInstructions: map[string][]string{"": {"C instructions"}},
 	},
 }
@@ -237,3 +243,127 @@ func EnsureRecipe(t *testing.T, got *mela.Recipe, wantID string) {
 func durationsSame(want, got *time.Duration) bool {
 	return (want == nil && got != nil) || (want != nil && *got != *want)
 }
+
+func TestAppleDate(t *testing.T) {
+	recipes, err := mela.Open("fixtures/a.melarecipe")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := time.Date(2022, time.August, 18, 7, 16, 23, 287215948, time.UTC)
+	if got := recipes[0].Date.Time(); !got.Equal(want) {
+		t.Errorf("Incorrect date: want = %v, got = %v", want, got)
+	}
+
+	if got := mela.NewAppleDate(want); got != recipes[0].Date {
+		t.Errorf("Incorrect apple date: want = %f, got = %f", recipes[0].Date, got)
+	}
+}
+
+func TestRecipes_SetReproducible(t *testing.T) {
+	recipes, err := mela.Open("fixtures/a+b.melarecipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := mela.Open("fixtures/c.melarecipe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c[0].Filename = "C title"
+	recipes = append(recipes, c[0])
+
+	bundle := func(order ...int) []byte {
+		buf := new(bytes.Buffer)
+		rs := mela.NewRecipesWriter(buf)
+		if err := rs.SetReproducible(); err != nil {
+			t.Fatal(err)
+		}
+		for _, i := range order {
+			if err := rs.Add(recipes[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := rs.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	first := bundle(0, 1, 2)
+	if second := bundle(2, 0, 1); !bytes.Equal(first, second) {
+		t.Error("Bundles of the same recipes, added in a different order, differ")
+	}
+
+	var names []string
+	err = mela.ParseRecipes(bytes.NewReader(first), int64(len(first)), func(r *mela.Recipe, err error) {
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		names = append(names, r.Filename)
+		EnsureRecipe(t, r, r.ID)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"A title", "B title", "C title"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Incorrect recipe order: want = %v, got = %v", want, names)
+	}
+
+	rs := mela.NewRecipesWriter(new(bytes.Buffer))
+	if err := rs.Add(recipes[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.SetReproducible(); err == nil {
+		t.Error("Expected an error making a bundle reproducible after adding recipes")
+	}
+}
+
+func TestRecipes_SetReproducible_SameFilename(t *testing.T) {
+	a, err := mela.Open("fixtures/a.melarecipe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := mela.Open("fixtures/c.melarecipe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a[0].Filename, c[0].Filename = "Clash", "Clash"
+
+	bundle := func(recipes ...*mela.Recipe) []byte {
+		buf := new(bytes.Buffer)
+		rs := mela.NewRecipesWriter(buf)
+		if err := rs.SetReproducible(); err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range recipes {
+			if err := rs.Add(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := rs.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	first := bundle(a[0], c[0])
+	if second := bundle(c[0], a[0]); !bytes.Equal(first, second) {
+		t.Error("Bundles of recipes with the same filename, added in a different order, differ")
+	}
+
+	var names []string
+	err = mela.ParseRecipes(bytes.NewReader(first), int64(len(first)), func(r *mela.Recipe, err error) {
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		names = append(names, r.Filename)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Clash-2", "Clash"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Incorrect recipe names: want = %v, got = %v", want, names)
+	}
+}
@@ -2,16 +2,40 @@ package mela
 
 import (
 	"archive/zip"
+	"bytes"
+	"compress/flate"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
 	"io"
 	"os"
 	"path"
+	"sort"
+	"time"
 )
 
 type Recipes struct {
-	zip *zip.Writer
+	f     io.Closer
+	zip   *zip.Writer
+	names map[string]bool
+
+	reproducible bool
+	// entries are the compressed recipes of a reproducible bundle, written in order when it's closed
+	entries []reproducibleEntry
+}
+
+// reproducibleEntry is a recipe's file within a reproducible bundle, already compressed. Its name in the bundle is only
+// chosen when the bundle is closed, so recipes with the same filename are named the same whatever order they're added.
+type reproducibleEntry struct {
+	filename string
+	header   zip.FileHeader
+	data     []byte
 }
 
+// reproducibleModTime is the modification time given to every file in a reproducible bundle: the earliest a zip can hold.
+var reproducibleModTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
 // ParseRecipe parses a known .melarecipes collection file into a stream of Recipe-compatible structs, calling the onRecipe func for each, as it is parsed
 func ParseRecipes(r io.ReaderAt, size int64, onRecipe func(*Recipe, error)) error {
 	zr, err := zip.NewReader(r, size)
@@ -20,16 +44,10 @@ func ParseRecipes(r io.ReaderAt, size int64, onRecipe func(*Recipe, error)) erro
 	}
 
 	for _, zf := range zr.File {
-		rr, err := zf.Open()
+		recipe, err := parseZippedRecipe(zf)
 		if err != nil {
 			onRecipe(nil, err)
-		}
-		defer rr.Close()
-
-		if recipe, err := ParseRecipe(rr); err != nil {
-			onRecipe(nil, err)
 		} else {
-			recipe.Filename = withoutExt(zf.Name)
 			onRecipe(recipe, nil)
 		}
 	}
@@ -37,28 +55,182 @@ func ParseRecipes(r io.ReaderAt, size int64, onRecipe func(*Recipe, error)) erro
 	return nil
 }
 
+// parseZippedRecipe parses one recipe from a .melarecipes file, closing it as soon as it's read so only one recipe is
+// held open at a time.
+func parseZippedRecipe(zf *zip.File) (*Recipe, error) {
+	rr, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rr.Close()
+
+	recipe, err := ParseRecipe(rr)
+	if err != nil {
+		return nil, err
+	}
+	recipe.Filename = withoutExt(zf.Name)
+	return recipe, nil
+}
+
 // NewRecipesBundle creates a .melarecipes (zip file) and allows writing new recipes directly to it with .Add().
 func NewRecipesBundle(dir, name string) (*Recipes, error) {
+	return NewRecipesBundleFS(DirFS(dir), ".", name)
+}
+
+// NewRecipesBundleFS creates a .melarecipes file in the directory dir of fsys, as NewRecipesBundle does.
+func NewRecipesBundleFS(fsys WriteFS, dir, name string) (*Recipes, error) {
 	filename := path.Join(dir, stringToFilename(name)+".melarecipes")
-	f, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	f, err := fsys.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
 	if err != nil {
 		return nil, err
 	}
 
+	rs := NewRecipesWriter(f)
+	rs.f = f
+	return rs, nil
+}
+
+// NewRecipesWriter writes a .melarecipes bundle to w, with recipes added by .Add(). Closing the bundle doesn't close w.
+func NewRecipesWriter(w io.Writer) *Recipes {
 	return &Recipes{
-		zip: zip.NewWriter(f),
-	}, nil
+		zip:   zip.NewWriter(w),
+		names: make(map[string]bool),
+	}
+}
+
+// SetReproducible makes the bundle reproducible: the same recipes always give a byte-identical file, whatever order
+// they're added in and whenever it's made. Recipes are written as normalised JSON, sorted by their names in the bundle,
+// with fixed modification times and compression settings. They're held (compressed) in memory until the bundle is
+// closed, so they can be sorted. It must be called before any recipes are added.
+func (rs *Recipes) SetReproducible() error {
+	if len(rs.names) > 0 {
+		return errors.New("recipes have already been added to the bundle")
+	}
+	rs.reproducible = true
+	return nil
 }
 
 func (rs *Recipes) Close() error {
-	return rs.zip.Close()
+	if err := rs.writeReproducible(); err != nil {
+		return err
+	}
+	if err := rs.zip.Close(); err != nil {
+		return err
+	}
+	if rs.f == nil {
+		return nil
+	}
+	return rs.f.Close()
 }
 
+// Add writes the recipe to the bundle. If the bundle already holds a recipe with the same Filename, this one is given a
+// numbered suffix (eg. "pancakes-2") within the bundle, so both are kept. In a reproducible bundle, the suffixes are
+// given by the recipes' contents instead, when the bundle is closed.
 func (rs *Recipes) Add(r *Recipe) error {
-	w, err := rs.zip.Create(r.Filename + ".melarecipe")
+	if rs.reproducible {
+		rs.names[r.Filename] = true
+		return rs.addReproducible(r)
+	}
+
+	name := uniqueName(rs.names, r.Filename)
+
+	w, err := rs.zip.Create(name + ".melarecipe")
 	if err != nil {
 		return err
 	}
 
 	return json.NewEncoder(w).Encode(r)
 }
+
+// addZipped adds a recipe's file from another .melarecipes file, copying it as it is, unless it must be rewritten.
+func (rs *Recipes) addZipped(zf *zip.File) error {
+	name := withoutExt(zf.Name)
+	if rs.reproducible || rs.names[name] {
+		r, err := parseZippedRecipe(zf)
+		if err != nil {
+			return err
+		}
+		return rs.Add(r)
+	}
+
+	rs.names[name] = true
+	return rs.zip.Copy(zf)
+}
+
+// uniqueName gives a name not yet in names, by adding a numbered suffix if needed, and adds it.
+func uniqueName(names map[string]bool, name string) string {
+	unique := name
+	for i := 2; names[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	names[unique] = true
+	return unique
+}
+
+func (rs *Recipes) addReproducible(r *Recipe) error {
+	// Missing lists are written as empty ones, so they're always the same
+	norm := *r
+	if norm.Categories == nil {
+		norm.Categories = make([]string, 0)
+	}
+	if norm.Images == nil {
+		norm.Images = make([]B64Image, 0)
+	}
+	data, err := json.Marshal(norm)
+	if err != nil {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	fw, err := flate.NewWriter(buf, flate.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return err
+	}
+	if err := fw.Close(); err != nil {
+		return err
+	}
+
+	rs.entries = append(rs.entries, reproducibleEntry{
+		filename: r.Filename,
+		header: zip.FileHeader{
+			Method:             zip.Deflate,
+			Modified:           reproducibleModTime,
+			CRC32:              crc32.ChecksumIEEE(data),
+			CompressedSize64:   uint64(buf.Len()),
+			UncompressedSize64: uint64(len(data)),
+		},
+		data: buf.Bytes(),
+	})
+	return nil
+}
+
+func (rs *Recipes) writeReproducible() error {
+	// Recipes with the same filename are numbered in the order of their contents, so the names don't depend on the order
+	// they were added in
+	sort.Slice(rs.entries, func(i, j int) bool {
+		if rs.entries[i].filename != rs.entries[j].filename {
+			return rs.entries[i].filename < rs.entries[j].filename
+		}
+		return bytes.Compare(rs.entries[i].data, rs.entries[j].data) < 0
+	})
+	names := make(map[string]bool)
+	for i := range rs.entries {
+		rs.entries[i].header.Name = uniqueName(names, rs.entries[i].filename) + ".melarecipe"
+	}
+
+	sort.Slice(rs.entries, func(i, j int) bool { return rs.entries[i].header.Name < rs.entries[j].header.Name })
+	for _, e := range rs.entries {
+		w, err := rs.zip.CreateRaw(&e.header)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(e.data); err != nil {
+			return err
+		}
+	}
+	rs.entries = nil
+	return nil
+}
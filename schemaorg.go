@@ -0,0 +1,473 @@
+package mela
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// schemaNode is a schema.org object, as decoded from JSON-LD or collected from microdata or RDFa.
+type schemaNode map[string]any
+
+// isType reports whether the node has the given schema.org type, in any of the forms it can be written
+// (eg. "Recipe", "schema:Recipe" or "http://schema.org/Recipe").
+func (n schemaNode) isType(want string) bool {
+	for _, t := range schemaStrings(n["@type"]) {
+		if schemaLocalName(t) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaLocalName strips any vocabulary prefix from a type or property name.
+func schemaLocalName(name string) string {
+	if i := strings.LastIndexAny(name, "/#:"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// findSchemaNodes walks decoded JSON-LD (including @graph arrays and nested values) for nodes of the given type.
+func findSchemaNodes(v any, typ string) []schemaNode {
+	var found []schemaNode
+	switch val := v.(type) {
+	case []any:
+		for _, item := range val {
+			found = append(found, findSchemaNodes(item, typ)...)
+		}
+	case map[string]any:
+		n := schemaNode(val)
+		if n.isType(typ) {
+			return []schemaNode{n}
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			found = append(found, findSchemaNodes(val[k], typ)...)
+		}
+	case schemaNode:
+		return findSchemaNodes(map[string]any(val), typ)
+	}
+	return found
+}
+
+// schemaStrings flattens a property value into its text values.
+func schemaStrings(v any) []string {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		if s := strings.TrimSpace(val); s != "" {
+			return []string{s}
+		}
+		return nil
+	case float64:
+		return []string{strconv.FormatFloat(val, 'f', -1, 64)}
+	case []any:
+		var out []string
+		for _, item := range val {
+			out = append(out, schemaStrings(item)...)
+		}
+		return out
+	case map[string]any:
+		return schemaStrings(schemaNode(val))
+	case schemaNode:
+		for _, key := range []string{"@value", "text", "name", "url", "contentUrl", "@id"} {
+			if s := schemaStrings(val[key]); s != nil {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// schemaObject finds the first node in a property value, as JSON-LD objects or as nodes collected from microdata or RDFa.
+func schemaObject(v any) schemaNode {
+	switch val := v.(type) {
+	case map[string]any:
+		return val
+	case schemaNode:
+		return val
+	case []any:
+		for _, item := range val {
+			if n := schemaObject(item); n != nil {
+				return n
+			}
+		}
+	}
+	return nil
+}
+
+func schemaString(v any) string {
+	s := schemaStrings(v)
+	if len(s) == 0 {
+		return ""
+	}
+	return s[0]
+}
+
+// recipeFromSchema maps a schema.org Recipe onto a Recipe.
+func recipeFromSchema(n schemaNode) *Recipe {
+	r := &Recipe{
+		Title:      schemaString(n["name"]),
+		Text:       schemaString(n["description"]),
+		Categories: make([]string, 0),
+		Images:     make([]B64Image, 0),
+	}
+
+	r.Link = schemaString(n["url"])
+	if r.Link == "" {
+		if id := schemaString(n["@id"]); strings.HasPrefix(id, "http") {
+			r.Link = id
+		}
+	}
+	r.ID = r.Link
+
+	if id := schemaString(n["@id"]); strings.HasPrefix(id, "urn:") {
+		r.ID = id
+	}
+	if r.Book() == nil {
+		bookFromSchema(r, n)
+	}
+	if book := schemaObject(n["isPartOf"]); book != nil && r.Link == "" {
+		r.Link = schemaString(book["name"])
+	}
+
+	ingredients := schemaStrings(n["recipeIngredient"])
+	if ingredients == nil {
+		ingredients = schemaStrings(n["ingredients"])
+	}
+	r.Ingredients = SectionedSequence(strings.Join(ingredients, "\n"))
+	r.Instructions = NewSectionedSequence(schemaInstructions(n["recipeInstructions"]))
+
+	for _, c := range schemaStrings(n["recipeCategory"]) {
+		for _, part := range strings.Split(c, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				r.Categories = append(r.Categories, part)
+			}
+		}
+	}
+
+	r.PrepTime = schemaDuration(n["prepTime"])
+	r.CookTime = schemaDuration(n["cookTime"])
+	r.TotalTime = schemaDuration(n["totalTime"])
+	r.Yield = schemaYield(n["recipeYield"])
+	r.Nutrition = schemaNutrition(n["nutrition"])
+
+	r.Images = append(r.Images, schemaImages(n["image"])...)
+
+	return r
+}
+
+// bookFromSchema sets a book-based ID from an isbn on the recipe, or on a Book it's part of.
+func bookFromSchema(r *Recipe, n schemaNode) {
+	isbn := schemaString(n["isbn"])
+	var pages Pages
+	if book := schemaObject(n["isPartOf"]); book != nil && isbn == "" {
+		isbn = schemaString(book["isbn"])
+		pages, _ = ParsePages(schemaString(book["pagination"]))
+	}
+
+	if isbn != "" {
+		_ = r.SetBook(isbn, pages, 0)
+	}
+}
+
+// schemaInstructions handles instructions as a single string, a list of strings or HowToSteps, and HowToSections of
+// those.
+func schemaInstructions(v any) []Section {
+	var sections []Section
+	current := func() *Section {
+		if len(sections) == 0 {
+			sections = append(sections, Section{})
+		}
+		return &sections[len(sections)-1]
+	}
+
+	var walk func(any)
+	walk = func(v any) {
+		switch val := v.(type) {
+		case string:
+			for _, line := range strings.Split(val, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					current().Lines = append(current().Lines, line)
+				}
+			}
+		case []any:
+			for _, item := range val {
+				walk(item)
+			}
+		case map[string]any:
+			walk(schemaNode(val))
+		case schemaNode:
+			switch {
+			case val.isType("HowToSection"):
+				sections = append(sections, Section{Heading: schemaString(val["name"])})
+				walk(val["itemListElement"])
+			case val.isType("ItemList"):
+				walk(val["itemListElement"])
+			case val["text"] != nil:
+				walk(schemaString(val["text"]))
+			default:
+				walk(schemaString(val))
+			}
+		}
+	}
+	walk(v)
+
+	return sections
+}
+
+func schemaDuration(v any) MaybeDuration {
+	str := schemaString(v)
+	if str == "" {
+		return ""
+	}
+
+	d, err := MaybeDuration(str).Parse()
+	if err != nil || d == nil {
+		return MaybeDuration(str)
+	}
+	return FormatDuration(*d, DurationStyleLong)
+}
+
+// schemaYield picks the most descriptive of the yields given, as sites often give both "4" and "4 slices".
+func schemaYield(v any) PeopleCount {
+	var raw PeopleCount
+	var best *Yield
+	for _, str := range schemaStrings(v) {
+		y, err := ParseYield(str)
+		if err != nil {
+			if raw == "" {
+				raw = PeopleCount(str)
+			}
+			continue
+		}
+		if best == nil || (best.Unit == yieldServings && y.Unit != yieldServings) {
+			best = y
+		}
+	}
+
+	if best == nil {
+		return raw
+	}
+	return best.Format()
+}
+
+var nutritionProperties = []struct{ key, label string }{
+	{"servingSize", "Serving size"},
+	{"calories", "Calories"},
+	{"fatContent", "Fat"},
+	{"saturatedFatContent", "Saturated fat"},
+	{"transFatContent", "Trans fat"},
+	{"unsaturatedFatContent", "Unsaturated fat"},
+	{"carbohydrateContent", "Carbohydrates"},
+	{"sugarContent", "Sugar"},
+	{"fiberContent", "Fibre"},
+	{"proteinContent", "Protein"},
+	{"cholesterolContent", "Cholesterol"},
+	{"sodiumContent", "Sodium"},
+}
+
+func schemaNutrition(v any) string {
+	var n schemaNode
+	switch val := v.(type) {
+	case map[string]any:
+		n = val
+	case schemaNode:
+		n = val
+	default:
+		return schemaString(v)
+	}
+
+	var lines []string
+	for _, p := range nutritionProperties {
+		if val := schemaString(n[p.key]); val != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", p.label, val))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// schemaImages decodes any images embedded as data URIs; images referenced by URL are ignored, as fetching them
+// would need network access.
+func schemaImages(v any) []B64Image {
+	var imgs []B64Image
+	switch val := v.(type) {
+	case []any:
+		for _, item := range val {
+			imgs = append(imgs, schemaImages(item)...)
+		}
+	case map[string]any:
+		imgs = schemaImages(schemaNode(val))
+	case schemaNode:
+		for _, key := range []string{"contentUrl", "url"} {
+			if found := schemaImages(val[key]); found != nil {
+				return found
+			}
+		}
+	case string:
+		if img, err := decodeDataURI(val); err == nil {
+			imgs = append(imgs, img)
+		}
+	}
+	return imgs
+}
+
+func decodeDataURI(uri string) ([]byte, error) {
+	rest, ok := strings.CutPrefix(uri, "data:")
+	if !ok {
+		return nil, fmt.Errorf("not a data URI")
+	}
+	meta, data, ok := strings.Cut(rest, ",")
+	if !ok {
+		return nil, fmt.Errorf("malformed data URI")
+	}
+
+	if strings.HasSuffix(meta, ";base64") {
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+	}
+	decoded, err := url.PathUnescape(data)
+	return []byte(decoded), err
+}
+
+type schemaRecipe struct {
+	Context            string            `json:"@context"`
+	Type               string            `json:"@type"`
+	ID                 string            `json:"@id,omitempty"`
+	Name               string            `json:"name"`
+	Description        string            `json:"description,omitempty"`
+	URL                string            `json:"url,omitempty"`
+	Image              []string          `json:"image,omitempty"`
+	RecipeYield        string            `json:"recipeYield,omitempty"`
+	PrepTime           string            `json:"prepTime,omitempty"`
+	CookTime           string            `json:"cookTime,omitempty"`
+	TotalTime          string            `json:"totalTime,omitempty"`
+	RecipeCategory     []string          `json:"recipeCategory,omitempty"`
+	RecipeIngredient   []string          `json:"recipeIngredient"`
+	RecipeInstructions []any             `json:"recipeInstructions"`
+	Nutrition          map[string]string `json:"nutrition,omitempty"`
+	IsPartOf           *schemaBook       `json:"isPartOf,omitempty"`
+}
+
+type schemaHowToSection struct {
+	Type            string            `json:"@type"`
+	Name            string            `json:"name"`
+	ItemListElement []schemaHowToStep `json:"itemListElement"`
+}
+
+type schemaHowToStep struct {
+	Type string `json:"@type"`
+	Text string `json:"text"`
+}
+
+type schemaBook struct {
+	Type       string `json:"@type"`
+	Name       string `json:"name,omitempty"`
+	ISBN       string `json:"isbn"`
+	Pagination string `json:"pagination,omitempty"`
+}
+
+// MarshalJSONLD encodes the recipe as a schema.org Recipe in JSON-LD, with its images embedded as data URIs.
+func (r *Recipe) MarshalJSONLD() ([]byte, error) {
+	var uris []string
+	for _, img := range r.Images {
+		uris = append(uris, img.DataURI())
+	}
+	return r.MarshalJSONLDWithImageURLs(uris)
+}
+
+// MarshalJSONLDWithImageURLs encodes the recipe as a schema.org Recipe in JSON-LD, referencing the given image URLs
+// in place of the recipe's own images.
+func (r *Recipe) MarshalJSONLDWithImageURLs(imageURLs []string) ([]byte, error) {
+	sr := schemaRecipe{
+		Context:            "https://schema.org",
+		Type:               "Recipe",
+		Name:               r.Title,
+		Description:        r.Text,
+		Image:              imageURLs,
+		RecipeYield:        string(r.Yield),
+		PrepTime:           isoDuration(r.PrepTime),
+		CookTime:           isoDuration(r.CookTime),
+		TotalTime:          isoDuration(r.TotalTime),
+		RecipeCategory:     r.Categories,
+		RecipeIngredient:   make([]string, 0),
+		RecipeInstructions: make([]any, 0),
+		Nutrition:          nutritionToSchema(r.Nutrition),
+	}
+
+	if u, err := url.Parse(r.Link); err == nil && u.Host != "" {
+		sr.URL = r.Link
+	}
+	if strings.HasPrefix(r.ID, "urn:") || strings.HasPrefix(r.ID, "http") {
+		sr.ID = r.ID
+	}
+
+	if book := r.Book(); book != nil {
+		sr.IsPartOf = &schemaBook{Type: "Book", ISBN: book.ISBN13}
+		if len(book.Pages) > 0 {
+			sr.IsPartOf.Pagination = book.Pages.String()
+		}
+		if sr.URL == "" {
+			sr.IsPartOf.Name = r.Link
+		}
+	}
+
+	for _, s := range r.Ingredients.Sections() {
+		sr.RecipeIngredient = append(sr.RecipeIngredient, s.Lines...)
+	}
+
+	for _, s := range r.Instructions.Sections() {
+		var steps []schemaHowToStep
+		for _, line := range s.Lines {
+			steps = append(steps, schemaHowToStep{Type: "HowToStep", Text: line})
+		}
+
+		if s.Heading == "" {
+			for _, step := range steps {
+				sr.RecipeInstructions = append(sr.RecipeInstructions, step)
+			}
+			continue
+		}
+		sr.RecipeInstructions = append(sr.RecipeInstructions, schemaHowToSection{Type: "HowToSection", Name: s.Heading, ItemListElement: steps})
+	}
+
+	return json.MarshalIndent(sr, "", "  ")
+}
+
+func isoDuration(m MaybeDuration) string {
+	d, err := m.Parse()
+	if err != nil || d == nil {
+		return ""
+	}
+	return string(FormatDuration(*d, DurationStyleISO8601))
+}
+
+// nutritionToSchema reverses schemaNutrition, picking out the "Label: value" lines it understands.
+func nutritionToSchema(nutrition string) map[string]string {
+	obj := map[string]string{"@type": "NutritionInformation"}
+	for _, line := range strings.Split(nutrition, "\n") {
+		label, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		for _, p := range nutritionProperties {
+			if strings.EqualFold(strings.TrimSpace(label), p.label) {
+				obj[p.key] = strings.TrimSpace(val)
+			}
+		}
+	}
+
+	if len(obj) == 1 {
+		return nil
+	}
+	return obj
+}
@@ -0,0 +1,113 @@
+package mela_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func TestRecipe_MarshalJSONLD(t *testing.T) {
+	recipes, err := mela.Open("fixtures/c.melarecipe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := recipes[0]
+	if err := r.Standardize(false); err != nil {
+		t.Fatal(err)
+	}
+	r.Ingredients = "1 onion\n# Sauce\n400g tomatoes"
+	r.Instructions = "Chop the onion.\n# Sauce\nFry the onion.\nAdd the tomatoes."
+	r.Nutrition = "Calories: 200 kcal\nSomething else"
+
+	data, err := r.MarshalJSONLDWithImageURLs([]string{"https://example.com/c.jpg"})
+	if err != nil {
+		t.Fatalf("Unable to encode JSON-LD: %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unable to decode JSON-LD: %v", err)
+	}
+
+	want := map[string]any{
+		"@context":         "https://schema.org",
+		"@type":            "Recipe",
+		"@id":              "urn:isbn:9780714863603#pages=42&recipe=3",
+		"name":             "C title",
+		"description":      "C text",
+		"image":            []any{"https://example.com/c.jpg"},
+		"recipeYield":      "3",
+		"prepTime":         "PT3H",
+		"cookTime":         "PT30M",
+		"totalTime":        "PT3H30M",
+		"recipeCategory":   []any{"c", "cc"},
+		"recipeIngredient": []any{"1 onion", "400g tomatoes"},
+		"recipeInstructions": []any{
+			map[string]any{"@type": "HowToStep", "text": "Chop the onion."},
+			map[string]any{"@type": "HowToSection", "name": "Sauce", "itemListElement": []any{
+				map[string]any{"@type": "HowToStep", "text": "Fry the onion."},
+				map[string]any{"@type": "HowToStep", "text": "Add the tomatoes."},
+			}},
+		},
+		"nutrition": map[string]any{"@type": "NutritionInformation", "calories": "200 kcal"},
+		"isPartOf":  map[string]any{"@type": "Book", "name": "Fresh & Easy", "isbn": "9780714863603", "pagination": "42"},
+	}
+
+	for key, wantVal := range want {
+		if !reflect.DeepEqual(wantVal, got[key]) {
+			t.Errorf("Incorrect %s: want = %#v, got = %#v", key, wantVal, got[key])
+		}
+	}
+	for key := range got {
+		if _, ok := want[key]; !ok {
+			t.Errorf("Unexpected key %s: %#v", key, got[key])
+		}
+	}
+}
+
+func TestRecipe_MarshalJSONLD_RoundTrip(t *testing.T) {
+	for _, fixture := range []string{"a", "c"} {
+		recipes, err := mela.Open("fixtures/" + fixture + ".melarecipe")
+		if err != nil {
+			t.Fatal(err)
+		}
+		testJSONLDRoundTrip(t, recipes[0])
+	}
+}
+
+func testJSONLDRoundTrip(t *testing.T, r *mela.Recipe) {
+	if err := r.Standardize(false); err != nil {
+		t.Fatal(err)
+	}
+	r.Instructions = "# First\nDo a thing.\n# Second\nDo another thing."
+	r.Yield = "1 serving"
+
+	data, err := r.MarshalJSONLD()
+	if err != nil {
+		t.Fatalf("Unable to encode JSON-LD: %v", err)
+	}
+
+	got, err := mela.ParseHTML(strings.NewReader(`<script type="application/ld+json">` + string(data) + `</script>`))
+	if err != nil {
+		t.Fatalf("Unable to import JSON-LD: %v", err)
+	}
+
+	type roundTripped struct {
+		Title, Link                   string
+		Ingredients, Instructions     mela.SectionedSequence
+		PrepTime, CookTime, TotalTime mela.MaybeDuration
+		Yield                         mela.PeopleCount
+		Book                          *mela.Book
+	}
+	want := roundTripped{r.Title, r.Link, r.Ingredients, r.Instructions, r.PrepTime, r.CookTime, r.TotalTime, r.Yield, r.Book()}
+	gotFields := roundTripped{got.Title, got.Link, got.Ingredients, got.Instructions, got.PrepTime, got.CookTime, got.TotalTime, got.Yield, got.Book()}
+	if !reflect.DeepEqual(want, gotFields) {
+		t.Errorf("Recipe changed in round trip:\nwant = %#v\ngot  = %#v", want, gotFields)
+	}
+	if len(got.Images) != 1 || string(got.Images[0]) != string(r.Images[0]) {
+		t.Errorf("Image changed in round trip")
+	}
+}
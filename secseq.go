@@ -22,3 +22,46 @@ func (ss SectionedSequence) Parse() map[string][]string {
 	}
 	return sections
 }
+
+// Section is a run of lines in a SectionedSequence, under an optional heading.
+type Section struct {
+	Heading string
+	Lines   []string
+}
+
+var sectionHeading = regexp.MustCompile(`^\s*#+\s+(.+?)\s*$`)
+
+// Sections splits the sequence into its sections, in order, omitting blank lines. Lines before the first heading are
+// in a section with an empty heading.
+func (ss SectionedSequence) Sections() []Section {
+	var sections []Section
+	for _, line := range strings.Split(string(ss), "\n") {
+		if m := sectionHeading.FindStringSubmatch(line); m != nil {
+			sections = append(sections, Section{Heading: m[1]})
+			continue
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if len(sections) == 0 {
+			sections = append(sections, Section{})
+		}
+		sections[len(sections)-1].Lines = append(sections[len(sections)-1].Lines, line)
+	}
+	return sections
+}
+
+// NewSectionedSequence joins the given sections into the form Mela uses, with each heading prefixed by "# ".
+func NewSectionedSequence(sections []Section) SectionedSequence {
+	var lines []string
+	for _, s := range sections {
+		if s.Heading != "" {
+			lines = append(lines, "# "+s.Heading)
+		}
+		lines = append(lines, s.Lines...)
+	}
+	return SectionedSequence(strings.Join(lines, "\n"))
+}
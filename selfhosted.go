@@ -0,0 +1,164 @@
+package mela
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// favoriteRating is the star rating (out of 5) that Mela's favorite flag is equivalent to, in apps with ratings (Paprika,
+// Mealie).
+const favoriteRating = 5
+
+// structuredIngredient is an ingredient as the self-hosted recipe managers (Mealie, Tandoor) store them: an amount, unit,
+// food and note, or a heading for the ingredients after it.
+type structuredIngredient struct {
+	Heading string
+	Amount  float64
+	Unit    string
+	Food    string
+	Note    string
+	// Original is the line as written, when known
+	Original string
+}
+
+// line writes the ingredient as a Mela ingredient line, preferring the line as originally written.
+func (si structuredIngredient) line() string {
+	if si.Heading != "" {
+		return "# " + si.Heading
+	}
+	if si.Original != "" {
+		return si.Original
+	}
+
+	line := joinIngredientParts(quantityText(si.Amount), si.Unit, si.Food)
+	if si.Note != "" {
+		line += ", " + si.Note
+	}
+	return line
+}
+
+// structuredIngredients breaks each of the ingredient lines into its parts, keeping the line too.
+func structuredIngredients(ss SectionedSequence) []structuredIngredient {
+	var sis []structuredIngredient
+	for _, s := range ss.Sections() {
+		if s.Heading != "" {
+			sis = append(sis, structuredIngredient{Heading: s.Heading})
+		}
+		for _, line := range s.Lines {
+			ing := ParseIngredient(line)
+			sis = append(sis, structuredIngredient{
+				Amount:   ing.Quantity,
+				Unit:     ing.Unit,
+				Food:     ing.Name,
+				Note:     ing.Note,
+				Original: line,
+			})
+		}
+	}
+	return sis
+}
+
+func ingredientsFromStructured(sis []structuredIngredient) SectionedSequence {
+	var lines []string
+	for _, si := range sis {
+		if line := si.line(); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return SectionedSequence(strings.Join(lines, "\n"))
+}
+
+// quantityText writes an amount using fraction characters where possible, eg. "1½" rather than "1.5".
+func quantityText(n float64) string {
+	if n <= 0 {
+		return ""
+	}
+	whole, frac := math.Modf(n)
+	for glyph, value := range vulgarFractions {
+		if math.Abs(frac-value) < 0.01 {
+			if whole == 0 {
+				return string(glyph)
+			}
+			return formatQuantity(whole) + string(glyph)
+		}
+	}
+	return formatQuantity(math.Round(n*1000) / 1000)
+}
+
+// durationMinutes gives the duration in whole minutes, or zero if it can't be understood.
+func durationMinutes(m MaybeDuration) int {
+	d, err := m.Parse()
+	if err != nil || d == nil {
+		return 0
+	}
+	return int(d.Round(time.Minute) / time.Minute)
+}
+
+func minutesDuration(mins int) MaybeDuration {
+	if mins <= 0 {
+		return ""
+	}
+	return FormatDuration(time.Duration(mins)*time.Minute, DurationStyleLong)
+}
+
+var exportDateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05-0700", "2006-01-02T15:04:05.999999", time.DateTime, time.DateOnly}
+
+// parseExportDate reads the dates found in recipe manager exports, which vary in their precision and time zones.
+func parseExportDate(str string) (AppleDate, bool) {
+	for _, layout := range exportDateLayouts {
+		if t, err := time.Parse(layout, str); err == nil {
+			return NewAppleDate(t), true
+		}
+	}
+	return 0, false
+}
+
+// jpegImage re-encodes the image as a JPEG, for formats that only accept JPEG photos.
+func jpegImage(img B64Image) ([]byte, error) {
+	decoded, format, err := image.Decode(bytes.NewReader(img))
+	if err != nil {
+		return nil, err
+	}
+	if format == "jpeg" {
+		return img, nil
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, decoded, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readZipFile reads the whole of a file in a zip.
+func readZipFile(zf *zip.File) ([]byte, error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// writeZipFile adds a file to a zip, without compressing already compressed images.
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	method := zip.Deflate
+	if strings.HasPrefix(http.DetectContentType(data), "image/") {
+		method = zip.Store
+	}
+
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+	if err != nil {
+		return fmt.Errorf("unable to add %s: %w", name, err)
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,239 @@
+package mela
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ShoppingList is the combined set of ingredients needed for a number of recipes.
+type ShoppingList struct {
+	Items []*ShoppingItem `json:"items"`
+}
+
+// ShoppingItem is a single ingredient on a shopping list, totalled across every recipe that needs it.
+type ShoppingItem struct {
+	Name string `json:"name"`
+	// Quantity is zero when none of the recipes give an amount, eg. "Salt and pepper"
+	Quantity    float64  `json:"quantity,omitempty"`
+	MaxQuantity float64  `json:"maxQuantity,omitempty"`
+	Unit        string   `json:"unit,omitempty"`
+	Aisle       string   `json:"aisle"`
+	Recipes     []string `json:"recipes"`
+}
+
+// ScaledRecipe is a recipe to shop for, with a multiplier for its ingredients' quantities (eg. 2 to make double).
+type ScaledRecipe struct {
+	Recipe *Recipe
+	Scale  float64
+}
+
+// AisleMap categorises ingredients into the aisles of a shop, by any word in their name (eg. "onion" → "Produce").
+type AisleMap map[string]string
+
+// OtherAisle is used for ingredients that don't appear in the AisleMap.
+const OtherAisle = "Other"
+
+// DefaultAisles is a basic categorisation of common ingredients.
+var DefaultAisles = newAisleMap(map[string][]string{
+	"Produce": {"apple", "avocado", "basil", "carrot", "celery", "chilli", "coriander", "garlic", "ginger", "leek",
+		"lemon", "lime", "mint", "mushroom", "onion", "parsley", "potato", "shallot", "spinach", "tomato"},
+	"Meat & Fish":    {"bacon", "beef", "chicken", "cod", "lamb", "pork", "prawn", "salmon", "sausage"},
+	"Dairy & Eggs":   {"butter", "cheese", "cream", "egg", "feta", "milk", "parmesan", "yoghurt", "yogurt"},
+	"Baking":         {"flour", "sugar", "yeast", "chocolate", "cocoa", "vanilla"},
+	"Cupboard":       {"bean", "chickpea", "lentil", "noodle", "oil", "pasta", "rice", "stock", "vinegar"},
+	"Herbs & Spices": {"cinnamon", "cumin", "nutmeg", "paprika", "pepper", "salt", "turmeric"},
+})
+
+func newAisleMap(aisles map[string][]string) AisleMap {
+	m := make(AisleMap)
+	for aisle, words := range aisles {
+		for _, w := range words {
+			m[w] = aisle
+		}
+	}
+	return m
+}
+
+// NewShoppingList combines the ingredients of the given recipes, merging like ingredients where their units are
+// compatible, and groups them into aisles. A nil AisleMap puts everything in OtherAisle.
+func NewShoppingList(recipes []ScaledRecipe, aisles AisleMap) *ShoppingList {
+	sl := &ShoppingList{}
+	byKey := make(map[string]*ShoppingItem)
+
+	for _, sr := range recipes {
+		scale := sr.Scale
+		if scale == 0 {
+			scale = 1
+		}
+
+		for _, ing := range sr.Recipe.IngredientList() {
+			if ing.Name == "" {
+				continue
+			}
+
+			key := shoppingKey(ing)
+			item, ok := byKey[key]
+			if !ok {
+				item = &ShoppingItem{Name: ing.Name, Unit: ing.Unit, Aisle: aisles.aisleFor(ing.Name)}
+				byKey[key] = item
+				sl.Items = append(sl.Items, item)
+			}
+
+			item.add(ing.Quantity*scale, ing.MaxQuantity*scale, ing.Unit)
+			if len(item.Recipes) == 0 || item.Recipes[len(item.Recipes)-1] != sr.Recipe.Title {
+				item.Recipes = append(item.Recipes, sr.Recipe.Title)
+			}
+		}
+	}
+
+	for _, item := range sl.Items {
+		item.tidyUnit()
+	}
+
+	sort.SliceStable(sl.Items, func(i, j int) bool {
+		if sl.Items[i].Aisle != sl.Items[j].Aisle {
+			return sl.Items[i].Aisle < sl.Items[j].Aisle
+		}
+		return strings.ToLower(sl.Items[i].Name) < strings.ToLower(sl.Items[j].Name)
+	})
+
+	return sl
+}
+
+// shoppingKey groups ingredients with the same name whose units can be added together.
+func shoppingKey(ing Ingredient) string {
+	var words []string
+	for _, w := range wordSplitter.Split(strings.ToLower(ing.Name), -1) {
+		if w != "" {
+			words = append(words, singular(w))
+		}
+	}
+
+	group := ing.Unit
+	if u, ok := LookupUnit(ing.Unit); ok && u.Factor > 0 {
+		group = string(u.Dimension)
+	}
+
+	return strings.Join(words, " ") + "|" + group
+}
+
+func (aisles AisleMap) aisleFor(name string) string {
+	words := wordSplitter.Split(strings.ToLower(name), -1)
+	// The last word is usually the most telling, eg. "chicken stock" is stock
+	for i := len(words) - 1; i >= 0; i-- {
+		if aisle, ok := aisles[singular(words[i])]; ok {
+			return aisle
+		}
+		if aisle, ok := aisles[words[i]]; ok {
+			return aisle
+		}
+	}
+	return OtherAisle
+}
+
+func (item *ShoppingItem) add(quantity, maxQuantity float64, unit string) {
+	if unit != item.Unit {
+		// Different units of the same dimension are totalled in the dimension's base unit
+		from, _ := LookupUnit(unit)
+		to, _ := LookupUnit(item.Unit)
+		base := baseUnit(to.Dimension)
+		item.Quantity = item.Quantity*to.Factor + quantity*from.Factor
+		item.MaxQuantity = item.MaxQuantity*to.Factor + maxQuantity*from.Factor
+		item.Unit = base
+		return
+	}
+
+	item.Quantity += quantity
+	item.MaxQuantity += maxQuantity
+}
+
+func baseUnit(d Dimension) string {
+	if d == DimensionVolume {
+		return "ml"
+	}
+	return "g"
+}
+
+// tidyUnit moves large totals in base units up to the bigger unit, eg. 1500g to 1.5kg.
+func (item *ShoppingItem) tidyUnit() {
+	bigger := map[string]string{"g": "kg", "ml": "l"}[item.Unit]
+	if bigger == "" || item.Quantity < 1000 {
+		return
+	}
+
+	item.Quantity /= 1000
+	item.MaxQuantity /= 1000
+	item.Unit = bigger
+}
+
+func (item *ShoppingItem) String() string {
+	if item.Quantity == 0 && item.MaxQuantity == 0 {
+		return item.Name
+	}
+
+	out := formatQuantity(roundQuantity(item.Quantity))
+	if item.MaxQuantity != item.Quantity {
+		out += "–" + formatQuantity(roundQuantity(item.MaxQuantity))
+	}
+	if item.Unit != "" {
+		out += " " + item.Unit
+	}
+	return out + " " + item.Name
+}
+
+func roundQuantity(n float64) float64 {
+	return math.Round(n*100) / 100
+}
+
+// Aisles returns the shopping list's items grouped by aisle, and the aisle names in order.
+func (sl *ShoppingList) Aisles() ([]string, map[string][]*ShoppingItem) {
+	var names []string
+	aisles := make(map[string][]*ShoppingItem)
+	for _, item := range sl.Items {
+		if _, ok := aisles[item.Aisle]; !ok {
+			names = append(names, item.Aisle)
+		}
+		aisles[item.Aisle] = append(aisles[item.Aisle], item)
+	}
+	return names, aisles
+}
+
+// Markdown formats the shopping list as a Markdown checklist, with a heading for each aisle.
+func (sl *ShoppingList) Markdown() string {
+	var b strings.Builder
+	names, aisles := sl.Aisles()
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "## %s\n\n", name)
+		for _, item := range aisles[name] {
+			fmt.Fprintf(&b, "- [ ] %s\n", item)
+		}
+	}
+	return b.String()
+}
+
+// Text formats the shopping list as plain text, with each aisle's items indented beneath it.
+func (sl *ShoppingList) Text() string {
+	var b strings.Builder
+	names, aisles := sl.Aisles()
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s\n", name)
+		for _, item := range aisles[name] {
+			fmt.Fprintf(&b, "  %s\n", item)
+		}
+	}
+	return b.String()
+}
+
+// JSON formats the shopping list as JSON.
+func (sl *ShoppingList) JSON() ([]byte, error) {
+	return json.MarshalIndent(sl, "", "  ")
+}
@@ -0,0 +1,54 @@
+package mela_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/jphastings/mela-recipes"
+)
+
+func TestNewShoppingList(t *testing.T) {
+	a := &Recipe{Title: "Soup", Ingredients: "2 onions, chopped\n500g tomatoes\n1 tbsp olive oil\nSalt"}
+	b := &Recipe{Title: "Sauce", Ingredients: "# Base\n1 onion\n1kg tomatoes\n2 tsp olive oil\n2 cloves garlic\n# Finish\nA handful of basil"}
+
+	sl := NewShoppingList([]ScaledRecipe{{Recipe: a, Scale: 2}, {Recipe: b}}, DefaultAisles)
+
+	wantMarkdown := `## Cupboard
+
+- [ ] 40 ml olive oil
+
+## Herbs & Spices
+
+- [ ] Salt
+
+## Produce
+
+- [ ] 1 handful basil
+- [ ] 2 clove garlic
+- [ ] 5 onions
+- [ ] 2 kg tomatoes
+`
+	if got := sl.Markdown(); got != wantMarkdown {
+		t.Errorf("Incorrect markdown shopping list:\nwant = %s\ngot = %s", wantMarkdown, got)
+	}
+
+	wantText := "Cupboard\n  40 ml olive oil\n\nHerbs & Spices\n  Salt\n\nProduce\n  1 handful basil\n  2 clove garlic\n  5 onions\n  2 kg tomatoes\n"
+	if got := sl.Text(); got != wantText {
+		t.Errorf("Incorrect text shopping list:\nwant = %s\ngot = %s", wantText, got)
+	}
+
+	data, err := sl.JSON()
+	if err != nil {
+		t.Fatalf("Unable to create JSON shopping list: %v", err)
+	}
+	var parsed ShoppingList
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("Unable to parse JSON shopping list: %v", err)
+	}
+	if len(parsed.Items) != 6 {
+		t.Errorf("Incorrect number of items in JSON: want = 6, got = %d", len(parsed.Items))
+	}
+	if onions := parsed.Items[4]; onions.Quantity != 5 || len(onions.Recipes) != 2 {
+		t.Errorf("Incorrect onions in JSON: %#v", onions)
+	}
+}
@@ -19,6 +19,8 @@ func (r *Recipe) Standardize(network bool) error {
 		return err
 	}
 
+	normalizeTimes(r)
+
 	if r.Images == nil {
 		r.Images = make([]B64Image, 0)
 	}
@@ -27,6 +29,9 @@ func (r *Recipe) Standardize(network bool) error {
 		r.Categories = make([]string, 0)
 	}
 
+	// Before optimizing, so the highest resolution copy is the one kept
+	removeDuplicateImages(r)
+
 	for i, img := range r.Images {
 		newImg, err := img.Optimize()
 		if err != nil {
@@ -120,6 +125,48 @@ func ordinal(n uint64) string {
 	}
 }
 
+func normalizeTimes(r *Recipe) {
+	fields := []struct {
+		name  string
+		field *MaybeDuration
+	}{
+		{"prep time", &r.PrepTime},
+		{"cook time", &r.CookTime},
+		{"total time", &r.TotalTime},
+	}
+
+	parsed := make([]*time.Duration, len(fields))
+	for i, f := range fields {
+		d, err := f.field.Parse()
+		if err != nil {
+			r.warnings = append(r.warnings, fmt.Sprintf("unable to understand %s '%s'", f.name, *f.field))
+			continue
+		}
+		if d == nil {
+			continue
+		}
+		parsed[i] = d
+
+		if formatted := FormatDuration(*d, DurationStyleLong); formatted != *f.field {
+			r.standardizationsMade = append(r.standardizationsMade, fmt.Sprintf("reformatted %s from '%s' to '%s'", f.name, *f.field, formatted))
+			*f.field = formatted
+		}
+	}
+
+	prep, cook, total := parsed[0], parsed[1], parsed[2]
+	if prep == nil || cook == nil {
+		return
+	}
+
+	sum := *prep + *cook
+	if r.TotalTime == "" {
+		r.TotalTime = FormatDuration(sum, DurationStyleLong)
+		r.standardizationsMade = append(r.standardizationsMade, fmt.Sprintf("set total time from prep and cook times: %s", r.TotalTime))
+	} else if total != nil && *total < sum {
+		r.warnings = append(r.warnings, fmt.Sprintf("total time (%s) is less than prep and cook times combined (%s)", r.TotalTime, FormatDuration(sum, DurationStyleLong)))
+	}
+}
+
 type thingsResponse struct {
 	Status string   `json:"status"`
 	Result []string `json:"result"`
@@ -227,3 +274,8 @@ func linkFromOpenLibrary(r *Recipe) error {
 func (r *Recipe) ListStandardizations() []string {
 	return r.standardizationsMade
 }
+
+// ListWarnings returns any problems noticed during standardization that couldn't be corrected automatically.
+func (r *Recipe) ListWarnings() []string {
+	return r.warnings
+}
@@ -54,3 +54,41 @@ func TestRawRecipe_Standardize(t *testing.T) {
 	}
 
 }
+
+func TestRawRecipe_StandardizeTimes(t *testing.T) {
+	type test struct {
+		name         string
+		prep         MaybeDuration
+		cook         MaybeDuration
+		total        MaybeDuration
+		wantPrep     MaybeDuration
+		wantCook     MaybeDuration
+		wantTotal    MaybeDuration
+		wantWarnings int
+	}
+
+	tests := []test{
+		{"Reformats all", "90min", "1hour", "3 hours", "1 hr 30 mins", "1 hr", "3 hrs", 0},
+		{"Fills total", "10 mins", "PT20M", "", "10 mins", "20 mins", "30 mins", 0},
+		{"Total too short", "30 mins", "30 mins", "45 mins", "30 mins", "30 mins", "45 mins", 1},
+		{"Unparseable left alone", "overnight", "1 hr", "", "overnight", "1 hr", "", 1},
+		{"Nothing set", "", "", "", "", "", "", 0},
+	}
+
+	for _, test := range tests {
+		r := &Recipe{PrepTime: test.prep, CookTime: test.cook, TotalTime: test.total}
+		if err := r.Standardize(false); err != nil {
+			t.Errorf("Error standardizing for '%s': %v", test.name, err)
+			continue
+		}
+
+		if r.PrepTime != test.wantPrep || r.CookTime != test.wantCook || r.TotalTime != test.wantTotal {
+			t.Errorf("Incorrect times for '%s': want = %s/%s/%s, got = %s/%s/%s", test.name,
+				test.wantPrep, test.wantCook, test.wantTotal, r.PrepTime, r.CookTime, r.TotalTime)
+		}
+
+		if len(r.ListWarnings()) != test.wantWarnings {
+			t.Errorf("Incorrect warnings for '%s': want %d, got = %v", test.name, test.wantWarnings, r.ListWarnings())
+		}
+	}
+}
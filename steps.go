@@ -0,0 +1,196 @@
+package mela
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Step is a single instruction from a recipe's method.
+type Step struct {
+	// Number counts steps from 1 across the whole method, ignoring sections
+	Number  int
+	Section string
+	Text    string
+
+	Timers       []Timer
+	Temperatures []Temperature
+	// Ingredients holds the names of the recipe's ingredients that are mentioned in this step
+	Ingredients []string
+}
+
+// Timer is a length of time mentioned in a step, eg. "simmer for 20 minutes" or "rest 1–2 hours".
+type Timer struct {
+	// Text is the part of the step the timer was taken from
+	Text string
+	Min  time.Duration
+	// Max is equal to Min when the timer isn't a range
+	Max time.Duration
+}
+
+// TemperatureUnit is the scale a Temperature is given in.
+type TemperatureUnit string
+
+const (
+	Celsius    TemperatureUnit = "C"
+	Fahrenheit TemperatureUnit = "F"
+	GasMark    TemperatureUnit = "gas mark"
+)
+
+// Temperature is an oven or cooking temperature mentioned in a step, eg. "180°C".
+type Temperature struct {
+	// Text is the part of the step the temperature was taken from
+	Text  string
+	Value float64
+	Unit  TemperatureUnit
+	// Fan is true for temperatures given for fan-assisted ovens
+	Fan bool
+}
+
+const stepNumber = `(` + quantityNumber + `|half(?:\s+an?)?|an?|one|two|three|four|five|six|ten|twenty|thirty)`
+const stepTimeUnit = `(hours?|hrs?|minutes?|mins?|seconds?|secs?)\b`
+
+var stepTimer = regexp.MustCompile(`(?i)\b` + stepNumber + `(?:\s*(?:-|–|—|to)\s*` + stepNumber + `)?\s*` + stepTimeUnit +
+	`(?:\s*(?:and\s+)?` + stepNumber + `\s*` + stepTimeUnit + `)?`)
+var stepTemperature = regexp.MustCompile(`(?i)(\d{2,3})\s*(°|º|degrees?\s*)?\s*(C|F|celsius|fahrenheit|centigrade)\b(\s*fan)?|gas(?:\s*mark)?\s*(\d{1,2})`)
+
+// stepTemperatureContext is what must come before a temperature given only as a number and C or F (eg. "bake at 200C"),
+// so quantities like "100 C of water" aren't taken as temperatures.
+var stepTemperatureContext = regexp.MustCompile(`(?i)\b(?:at|to|oven|about|around)\s*$`)
+var stepPrefix = regexp.MustCompile(`(?i)^\s*(?:step\s*)?\d+[.):]\s*`)
+
+var stepNumberWords = map[string]float64{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "ten": 10, "twenty": 20, "thirty": 30,
+}
+
+// Steps splits the recipe's instructions into numbered steps, noting timers, temperatures and the ingredients each
+// step refers to.
+func (r *Recipe) Steps() []Step {
+	ings := r.IngredientList()
+
+	steps := r.Instructions.Steps()
+	for i, s := range steps {
+		for _, ing := range ings {
+			if ing.mentionedIn(s.Text) {
+				steps[i].Ingredients = append(steps[i].Ingredients, ing.Name)
+			}
+		}
+	}
+
+	return steps
+}
+
+// Steps splits an instructions list into numbered steps, one per line, noting timers and temperatures.
+func (ss SectionedSequence) Steps() []Step {
+	var steps []Step
+	for _, sec := range ss.Sections() {
+		for _, line := range sec.Lines {
+			text := stepPrefix.ReplaceAllString(line, "")
+			if text == "" {
+				continue
+			}
+
+			steps = append(steps, Step{
+				Number:       len(steps) + 1,
+				Section:      sec.Heading,
+				Text:         text,
+				Timers:       findTimers(text),
+				Temperatures: findTemperatures(text),
+			})
+		}
+	}
+	return steps
+}
+
+func findTimers(text string) []Timer {
+	var timers []Timer
+	for _, loc := range stepTimer.FindAllStringSubmatchIndex(text, -1) {
+		m := submatches(text, loc)
+		// "a second" is usually the ordinal (eg. "add a second egg"), unless it's how long to do something for
+		if isArticle(m[1]) && m[2] == "" && m[4] == "" && strings.HasPrefix(strings.ToLower(m[3]), "sec") &&
+			!strings.HasSuffix(strings.ToLower(strings.TrimSpace(text[:loc[0]])), "for") {
+			continue
+		}
+
+		unit := timeUnit(m[3])
+		min := stepQuantity(m[1]) * float64(unit)
+		max := min
+		if m[2] != "" {
+			max = stepQuantity(m[2]) * float64(unit)
+		}
+		// "1 hour 30 minutes" adds to both ends of the range
+		if m[4] != "" {
+			extra := stepQuantity(m[4]) * float64(timeUnit(m[5]))
+			min += extra
+			max += extra
+		}
+
+		timers = append(timers, Timer{Text: m[0], Min: time.Duration(min), Max: time.Duration(max)})
+	}
+	return timers
+}
+
+// submatches gives the text of each of a regexp match's groups, from their indexes.
+func submatches(text string, loc []int) []string {
+	m := make([]string, len(loc)/2)
+	for i := range m {
+		if loc[2*i] >= 0 {
+			m[i] = text[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return m
+}
+
+func isArticle(str string) bool {
+	str = strings.ToLower(str)
+	return str == "a" || str == "an"
+}
+
+func timeUnit(str string) time.Duration {
+	switch strings.ToLower(str)[0] {
+	case 'h':
+		return time.Hour
+	case 's':
+		return time.Second
+	default:
+		return time.Minute
+	}
+}
+
+func stepQuantity(str string) float64 {
+	// eg. "half an hour"
+	if strings.HasPrefix(strings.ToLower(str), "half") {
+		return 0.5
+	}
+	if n, ok := stepNumberWords[strings.ToLower(str)]; ok {
+		return n
+	}
+	n, _ := parseYieldNumber(str)
+	return n
+}
+
+func findTemperatures(text string) []Temperature {
+	var temps []Temperature
+	for _, loc := range stepTemperature.FindAllStringSubmatchIndex(text, -1) {
+		m := submatches(text, loc)
+		if m[5] != "" {
+			n, _ := strconv.ParseFloat(m[5], 64)
+			temps = append(temps, Temperature{Text: m[0], Value: n, Unit: GasMark})
+			continue
+		}
+
+		// A bare C or F needs a degree sign, "fan" or a word like "at" before it to be a temperature
+		if m[2] == "" && len(m[3]) == 1 && m[4] == "" && !stepTemperatureContext.MatchString(text[:loc[0]]) {
+			continue
+		}
+
+		n, _ := strconv.ParseFloat(m[1], 64)
+		unit := Celsius
+		if strings.HasPrefix(strings.ToLower(m[3]), "f") {
+			unit = Fahrenheit
+		}
+		temps = append(temps, Temperature{Text: strings.TrimSpace(m[0]), Value: n, Unit: unit, Fan: m[4] != ""})
+	}
+	return temps
+}
@@ -0,0 +1,90 @@
+package mela_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/jphastings/mela-recipes"
+)
+
+func TestRecipe_Steps(t *testing.T) {
+	r := &Recipe{
+		Ingredients: "2 banana shallots, sliced\n1 tbsp olive oil\n# Sauce\n400g tin tomatoes\nBasil leaves",
+		Instructions: "Preheat the oven to 200°C (180C fan)/gas mark 6.\n\n" +
+			"1. Heat the oil and fry the shallots for 5-10 mins.\n" +
+			"# Sauce\n" +
+			"Step 3: Add the tomatoes and simmer for 1 hour 30 minutes.\n" +
+			"Rest for 1–2 hours, then tear over the basil.",
+	}
+
+	want := []Step{
+		{Number: 1, Text: "Preheat the oven to 200°C (180C fan)/gas mark 6.", Temperatures: []Temperature{
+			{Text: "200°C", Value: 200, Unit: Celsius},
+			{Text: "180C fan", Value: 180, Unit: Celsius, Fan: true},
+			{Text: "gas mark 6", Value: 6, Unit: GasMark},
+		}},
+		{Number: 2, Text: "Heat the oil and fry the shallots for 5-10 mins.",
+			Timers:      []Timer{{Text: "5-10 mins", Min: 5 * time.Minute, Max: 10 * time.Minute}},
+			Ingredients: []string{"banana shallots", "olive oil"},
+		},
+		{Number: 3, Section: "Sauce", Text: "Add the tomatoes and simmer for 1 hour 30 minutes.",
+			Timers:      []Timer{{Text: "1 hour 30 minutes", Min: 90 * time.Minute, Max: 90 * time.Minute}},
+			Ingredients: []string{"tin tomatoes"},
+		},
+		{Number: 4, Section: "Sauce", Text: "Rest for 1–2 hours, then tear over the basil.",
+			Timers:      []Timer{{Text: "1–2 hours", Min: time.Hour, Max: 2 * time.Hour}},
+			Ingredients: []string{"Basil leaves"},
+		},
+	}
+
+	got := r.Steps()
+	if len(got) != len(want) {
+		t.Fatalf("Incorrect number of steps: want = %d, got = %d (%#v)", len(want), len(got), got)
+	}
+
+	for i := range want {
+		if !reflect.DeepEqual(want[i], got[i]) {
+			t.Errorf("Incorrect step %d: want = %#v, got = %#v", i+1, want[i], got[i])
+		}
+	}
+}
+
+func TestSectionedSequence_StepsMixedNumberTimers(t *testing.T) {
+	steps := SectionedSequence("Bake for 1½ hours.\nSimmer for 1 1/2 hours.").Steps()
+
+	want := [][]Timer{
+		{{Text: "1½ hours", Min: 90 * time.Minute, Max: 90 * time.Minute}},
+		{{Text: "1 1/2 hours", Min: 90 * time.Minute, Max: 90 * time.Minute}},
+	}
+	for i := range want {
+		if !reflect.DeepEqual(want[i], steps[i].Timers) {
+			t.Errorf("Incorrect timers for step %d: want = %#v, got = %#v", i+1, want[i], steps[i].Timers)
+		}
+	}
+}
+
+func TestSectionedSequence_StepsFalseTimersAndTemperatures(t *testing.T) {
+	steps := SectionedSequence("Add a second egg and stir a second time.\n" +
+		"Whisk for a second, then rest for half a minute.\n" +
+		"Pour in 100 C of water, then bake at 200C.").Steps()
+
+	wantTimers := [][]Timer{
+		nil,
+		{
+			{Text: "a second", Min: time.Second, Max: time.Second},
+			{Text: "half a minute", Min: 30 * time.Second, Max: 30 * time.Second},
+		},
+		nil,
+	}
+	wantTemps := [][]Temperature{nil, nil, {{Text: "200C", Value: 200, Unit: Celsius}}}
+
+	for i := range steps {
+		if !reflect.DeepEqual(wantTimers[i], steps[i].Timers) {
+			t.Errorf("Incorrect timers for step %d: want = %#v, got = %#v", i+1, wantTimers[i], steps[i].Timers)
+		}
+		if !reflect.DeepEqual(wantTemps[i], steps[i].Temperatures) {
+			t.Errorf("Incorrect temperatures for step %d: want = %#v, got = %#v", i+1, wantTemps[i], steps[i].Temperatures)
+		}
+	}
+}
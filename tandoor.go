@@ -0,0 +1,315 @@
+package mela
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+const tandoorRecipeFile = "recipe.json"
+const tandoorImageName = "image"
+
+// tandoorRecipe is the JSON structure of a recipe in a Tandoor Recipes export.
+type tandoorRecipe struct {
+	Name         string           `json:"name"`
+	Description  string           `json:"description"`
+	Keywords     []tandoorKeyword `json:"keywords"`
+	Steps        []tandoorStep    `json:"steps"`
+	WorkingTime  int              `json:"working_time"`
+	WaitingTime  int              `json:"waiting_time"`
+	Internal     bool             `json:"internal"`
+	Nutrition    map[string]any   `json:"nutrition"`
+	Servings     float64          `json:"servings"`
+	ServingsText string           `json:"servings_text"`
+	SourceURL    string           `json:"source_url"`
+}
+
+type tandoorKeyword struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type tandoorStep struct {
+	Name         string              `json:"name"`
+	Instruction  string              `json:"instruction"`
+	Ingredients  []tandoorIngredient `json:"ingredients"`
+	Time         int                 `json:"time"`
+	Order        int                 `json:"order"`
+	ShowAsHeader bool                `json:"show_as_header"`
+}
+
+type tandoorNamed struct {
+	Name string `json:"name"`
+}
+
+type tandoorIngredient struct {
+	Food         *tandoorNamed `json:"food"`
+	Unit         *tandoorNamed `json:"unit"`
+	Amount       float64       `json:"amount"`
+	Note         string        `json:"note"`
+	Order        int           `json:"order"`
+	IsHeader     bool          `json:"is_header"`
+	NoAmount     bool          `json:"no_amount"`
+	OriginalText string        `json:"original_text,omitempty"`
+}
+
+// tandoorNutrition maps Tandoor's nutrition fields onto the labels Mela nutrition lines use.
+var tandoorNutrition = []struct{ key, label string }{
+	{"calories", "Calories"},
+	{"carbohydrates", "Carbohydrates"},
+	{"fats", "Fat"},
+	{"proteins", "Protein"},
+}
+
+// ParseTandoorExport parses a Tandoor Recipes export zip, which holds a zip for each recipe containing its recipe.json
+// and image, into a stream of Recipes, calling the onRecipe func for each, as it is parsed.
+func ParseTandoorExport(r io.ReaderAt, size int64, onRecipe func(*Recipe, error)) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	for _, zf := range zr.File {
+		if path.Ext(zf.Name) != ".zip" {
+			continue
+		}
+
+		recipe, err := parseTandoorRecipe(zf)
+		if err != nil {
+			onRecipe(nil, fmt.Errorf("unable to parse '%s': %w", zf.Name, err))
+			continue
+		}
+		onRecipe(recipe, nil)
+	}
+
+	return nil
+}
+
+// parseTandoorRecipe reads a single recipe's zip from within an export.
+func parseTandoorRecipe(zf *zip.File) (*Recipe, error) {
+	data, err := readZipFile(zf)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var tr *tandoorRecipe
+	var img []byte
+	for _, f := range zr.File {
+		switch {
+		case f.Name == tandoorRecipeFile:
+			data, err := readZipFile(f)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(data, &tr); err != nil {
+				return nil, err
+			}
+		case withoutExt(f.Name) == tandoorImageName:
+			if img, err = readZipFile(f); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if tr == nil {
+		return nil, fmt.Errorf("no %s found", tandoorRecipeFile)
+	}
+
+	recipe := tr.toRecipe()
+	recipe.Filename = withoutExt(zf.Name)
+	if img != nil {
+		recipe.Images = append(recipe.Images, img)
+	}
+	return recipe, nil
+}
+
+func (tr tandoorRecipe) toRecipe() *Recipe {
+	r := &Recipe{
+		Title:      tr.Name,
+		Link:       tr.SourceURL,
+		Text:       tr.Description,
+		PrepTime:   minutesDuration(tr.WorkingTime),
+		CookTime:   minutesDuration(tr.WaitingTime),
+		Categories: make([]string, 0),
+		Images:     make([]B64Image, 0),
+	}
+	r.ID = r.Link
+
+	if tr.Servings > 0 {
+		r.Yield = PeopleCount(strings.TrimSpace(quantityText(tr.Servings) + " " + tr.ServingsText))
+	}
+	for _, kw := range tr.Keywords {
+		r.Categories = append(r.Categories, kw.Name)
+	}
+
+	var nutrition []string
+	for _, p := range tandoorNutrition {
+		if val := schemaString(tr.Nutrition[p.key]); val != "" {
+			nutrition = append(nutrition, fmt.Sprintf("%s: %s", p.label, val))
+		}
+	}
+	r.Nutrition = strings.Join(nutrition, "\n")
+
+	var ingredients []structuredIngredient
+	var sections []Section
+	for _, step := range tr.Steps {
+		for _, ti := range step.Ingredients {
+			if ti.IsHeader {
+				heading := ti.Note
+				if heading == "" && ti.Food != nil {
+					heading = ti.Food.Name
+				}
+				ingredients = append(ingredients, structuredIngredient{Heading: heading})
+				continue
+			}
+
+			si := structuredIngredient{Note: ti.Note, Original: ti.OriginalText}
+			if !ti.NoAmount {
+				si.Amount = ti.Amount
+			}
+			if ti.Unit != nil {
+				si.Unit = ti.Unit.Name
+			}
+			if ti.Food != nil {
+				si.Food = ti.Food.Name
+			}
+			ingredients = append(ingredients, si)
+		}
+
+		if step.Name != "" || len(sections) == 0 {
+			sections = append(sections, Section{Heading: step.Name})
+		}
+		for _, line := range strings.Split(step.Instruction, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				sections[len(sections)-1].Lines = append(sections[len(sections)-1].Lines, line)
+			}
+		}
+	}
+	r.Ingredients = ingredientsFromStructured(ingredients)
+	r.Instructions = NewSectionedSequence(sections)
+
+	return r
+}
+
+func tandoorFromRecipe(r *Recipe) *tandoorRecipe {
+	tr := &tandoorRecipe{
+		Name:        r.Title,
+		Description: r.Text,
+		Keywords:    make([]tandoorKeyword, 0),
+		Steps:       make([]tandoorStep, 0),
+		WorkingTime: durationMinutes(r.PrepTime),
+		WaitingTime: durationMinutes(r.CookTime),
+		Internal:    true,
+		Nutrition:   make(map[string]any),
+		SourceURL:   r.Link,
+	}
+
+	if y, err := r.Yield.Yield(); err == nil {
+		tr.Servings = y.Max
+		if y.Unit != yieldServings {
+			tr.ServingsText = y.Unit
+		}
+	}
+	for _, c := range r.Categories {
+		tr.Keywords = append(tr.Keywords, tandoorKeyword{Name: c})
+	}
+	for _, line := range strings.Split(r.Nutrition, "\n") {
+		label, val, _ := strings.Cut(line, ":")
+		for _, p := range tandoorNutrition {
+			if strings.EqualFold(strings.TrimSpace(label), p.label) {
+				tr.Nutrition[p.key] = strings.TrimSpace(val)
+			}
+		}
+	}
+
+	// Each instruction is a step, with the ingredients all given in the first
+	for _, s := range r.Instructions.Sections() {
+		for i, line := range s.Lines {
+			step := tandoorStep{Instruction: line, Ingredients: make([]tandoorIngredient, 0), Order: len(tr.Steps)}
+			if i == 0 {
+				step.Name = s.Heading
+			}
+			tr.Steps = append(tr.Steps, step)
+		}
+	}
+	if len(tr.Steps) == 0 {
+		tr.Steps = append(tr.Steps, tandoorStep{Ingredients: make([]tandoorIngredient, 0)})
+	}
+
+	for i, si := range structuredIngredients(r.Ingredients) {
+		ti := tandoorIngredient{Order: i}
+		if si.Heading != "" {
+			ti.IsHeader, ti.NoAmount, ti.Note = true, true, si.Heading
+		} else {
+			ti.Food = &tandoorNamed{Name: si.Food}
+			ti.Amount, ti.NoAmount, ti.Note, ti.OriginalText = si.Amount, si.Amount == 0, si.Note, si.Original
+			if si.Unit != "" {
+				ti.Unit = &tandoorNamed{Name: si.Unit}
+			}
+		}
+		tr.Steps[0].Ingredients = append(tr.Steps[0].Ingredients, ti)
+	}
+
+	return tr
+}
+
+// TandoorExport is a Tandoor Recipes export zip being written.
+type TandoorExport struct {
+	f   *os.File
+	zip *zip.Writer
+	n   int
+}
+
+// NewTandoorExport creates a Tandoor Recipes export zip file and allows writing new recipes directly to it with .Add().
+func NewTandoorExport(dir, name string) (*TandoorExport, error) {
+	filename := path.Join(dir, stringToFilename(name)+".zip")
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TandoorExport{
+		f:   f,
+		zip: zip.NewWriter(f),
+	}, nil
+}
+
+func (te *TandoorExport) Close() error {
+	if err := te.zip.Close(); err != nil {
+		return err
+	}
+	return te.f.Close()
+}
+
+// Add writes the recipe, with its first image, as Tandoor only keeps one.
+func (te *TandoorExport) Add(r *Recipe) error {
+	data, err := json.MarshalIndent(tandoorFromRecipe(r), "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to convert recipe: %w", err)
+	}
+
+	var buf bytes.Buffer
+	inner := zip.NewWriter(&buf)
+	if err := writeZipFile(inner, tandoorRecipeFile, data); err != nil {
+		return err
+	}
+	if len(r.Images) > 0 {
+		if err := writeZipFile(inner, tandoorImageName+imageExtension(r.Images[0]), r.Images[0]); err != nil {
+			return err
+		}
+	}
+	if err := inner.Close(); err != nil {
+		return err
+	}
+
+	te.n++
+	return writeZipFile(te.zip, fmt.Sprintf("%d.zip", te.n), buf.Bytes())
+}
@@ -0,0 +1,73 @@
+package mela_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func TestParseTandoorExport(t *testing.T) {
+	recipes := openExportFixture(t, mela.ParseTandoorExport, "fixtures/tandoor/export.zip")
+	if len(recipes) != 1 {
+		t.Fatalf("Incorrect number of recipes: want = 1, got = %d", len(recipes))
+	}
+
+	soup := recipes[0]
+	checks := []struct {
+		field     string
+		want, got any
+	}{
+		{"Filename", "17", soup.Filename},
+		{"Title", "Lentil Soup", soup.Title},
+		{"Ingredients", mela.SectionedSequence("1 onion, chopped\n2 tbsp olive oil\n# Soup\n200 g red lentils\n1½ l stock\nsalt"), soup.Ingredients},
+		{"Instructions", mela.SectionedSequence("Soften the onion in the oil.\n# Simmer\nAdd the lentils and stock.\nSimmer until soft."), soup.Instructions},
+		{"Categories", []string{"Soup", "Vegetarian"}, soup.Categories},
+		{"Yield", mela.PeopleCount("4 bowls"), soup.Yield},
+		{"PrepTime", mela.MaybeDuration("10 mins"), soup.PrepTime},
+		{"CookTime", mela.MaybeDuration("25 mins"), soup.CookTime},
+		{"Nutrition", "Calories: 300\nCarbohydrates: 40\nFat: 5.5\nProtein: 18", soup.Nutrition},
+		{"Images", 1, len(soup.Images)},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.want, c.got) {
+			t.Errorf("Incorrect %s: want = %#v, got = %#v", c.field, c.want, c.got)
+		}
+	}
+}
+
+func TestTandoorExport_RoundTrip(t *testing.T) {
+	want := exportRecipe(t)
+
+	dir := t.TempDir()
+	export, err := mela.NewTandoorExport(dir, "Round Trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := export.Add(want); err != nil {
+		t.Fatal(err)
+	}
+	if err := export.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	recipes := openExportFixture(t, mela.ParseTandoorExport, filepath.Join(dir, "round-trip.zip"))
+	if len(recipes) != 1 {
+		t.Fatalf("Incorrect number of recipes: want = 1, got = %d", len(recipes))
+	}
+
+	got := recipes[0]
+	if got.ID != want.ID || got.Title != want.Title || got.Text != want.Text || got.Ingredients != want.Ingredients ||
+		got.Instructions != want.Instructions || got.Nutrition != want.Nutrition || got.PrepTime != want.PrepTime ||
+		got.CookTime != want.CookTime || !reflect.DeepEqual(got.Categories, want.Categories) {
+		t.Errorf("Recipe changed in round trip: want = %#v, got = %#v", want, got)
+	}
+	if got.Yield != "6" {
+		t.Errorf("Incorrect yield: want = \"6\", got = %q", got.Yield)
+	}
+	if len(got.Images) != 1 || !bytes.Equal(got.Images[0], want.Images[0]) {
+		t.Error("Image changed in round trip")
+	}
+}
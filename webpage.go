@@ -0,0 +1,217 @@
+package mela
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var ErrNoRecipeFound = errors.New("no schema.org Recipe was found in the given document")
+
+// ParseHTML finds the first schema.org Recipe in an HTML document and maps it onto a Recipe. See ParseHTMLRecipes.
+func ParseHTML(r io.Reader) (*Recipe, error) {
+	recipes, err := ParseHTMLRecipes(r)
+	if err != nil {
+		return nil, err
+	}
+	return recipes[0], nil
+}
+
+// ParseHTMLRecipes finds every schema.org Recipe in an HTML document, whether given as JSON-LD, microdata or RDFa, and
+// maps them onto Recipes. Images are only imported when embedded in the document as data URIs.
+func ParseHTMLRecipes(r io.Reader) ([]*Recipe, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []schemaNode
+	nodes = append(nodes, jsonLDNodes(doc)...)
+	nodes = append(nodes, itemNodes(doc, microdataAttrs)...)
+	nodes = append(nodes, itemNodes(doc, rdfaAttrs)...)
+
+	// Recipes can be nested in other items, eg. as a WebPage's mainEntity
+	var recipes []*Recipe
+	for _, n := range nodes {
+		for _, found := range findSchemaNodes(n, "Recipe") {
+			recipes = append(recipes, recipeFromSchema(found))
+		}
+	}
+
+	if len(recipes) == 0 {
+		return nil, ErrNoRecipeFound
+	}
+	return recipes, nil
+}
+
+func jsonLDNodes(doc *html.Node) []schemaNode {
+	var nodes []schemaNode
+	walkHTML(doc, func(n *html.Node) bool {
+		if n.DataAtom != atom.Script || !strings.EqualFold(htmlAttr(n, "type"), "application/ld+json") {
+			return true
+		}
+
+		var data any
+		if err := json.Unmarshal([]byte(htmlText(n)), &data); err == nil {
+			nodes = append(nodes, findSchemaNodes(data, "Recipe")...)
+		}
+		return false
+	})
+	return nodes
+}
+
+// itemAttrs names the attributes that mark up schema.org items in a given HTML syntax.
+type itemAttrs struct {
+	scope string
+	typ   string
+	prop  string
+}
+
+var microdataAttrs = itemAttrs{scope: "itemscope", typ: "itemtype", prop: "itemprop"}
+var rdfaAttrs = itemAttrs{scope: "typeof", typ: "typeof", prop: "property"}
+
+// itemNodes collects the top-level items marked up with microdata or RDFa into schemaNodes. Items nested within them
+// are collected as their properties.
+func itemNodes(doc *html.Node, attrs itemAttrs) []schemaNode {
+	var nodes []schemaNode
+	walkHTML(doc, func(n *html.Node) bool {
+		if !hasHTMLAttr(n, attrs.scope) {
+			return true
+		}
+		nodes = append(nodes, collectItem(n, attrs))
+		return false
+	})
+	return nodes
+}
+
+func collectItem(item *html.Node, attrs itemAttrs) schemaNode {
+	types := make([]any, 0)
+	for _, t := range strings.Fields(htmlAttr(item, attrs.typ)) {
+		types = append(types, t)
+	}
+	node := schemaNode{"@type": types}
+
+	for c := item.FirstChild; c != nil; c = c.NextSibling {
+		walkHTML(c, func(n *html.Node) bool {
+			props := strings.Fields(htmlAttr(n, attrs.prop))
+			isItem := hasHTMLAttr(n, attrs.scope)
+			if len(props) == 0 {
+				// Nested items without properties aren't part of this item
+				return !isItem
+			}
+
+			var val any
+			if isItem {
+				val = collectItem(n, attrs)
+			} else {
+				val = itemValue(n)
+			}
+
+			for _, p := range props {
+				p = schemaLocalName(p)
+				switch existing := node[p].(type) {
+				case nil:
+					node[p] = val
+				case []any:
+					node[p] = append(existing, val)
+				default:
+					node[p] = []any{existing, val}
+				}
+			}
+			return !isItem
+		})
+	}
+
+	return node
+}
+
+func itemValue(n *html.Node) any {
+	if content, ok := lookupHTMLAttr(n, "content"); ok {
+		return content
+	}
+
+	switch n.DataAtom {
+	case atom.Img, atom.Audio, atom.Video, atom.Source, atom.Embed, atom.Iframe:
+		return htmlAttr(n, "src")
+	case atom.A, atom.Area, atom.Link:
+		return htmlAttr(n, "href")
+	case atom.Object:
+		return htmlAttr(n, "data")
+	case atom.Time:
+		if dt, ok := lookupHTMLAttr(n, "datetime"); ok {
+			return dt
+		}
+	case atom.Data, atom.Meter:
+		return htmlAttr(n, "value")
+	}
+
+	return htmlText(n)
+}
+
+// walkHTML visits every node depth first, only descending into a node's children when visit returns true.
+func walkHTML(n *html.Node, visit func(*html.Node) bool) {
+	if !visit(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkHTML(c, visit)
+	}
+}
+
+func lookupHTMLAttr(n *html.Node, key string) (string, bool) {
+	if n.Type != html.ElementNode {
+		return "", false
+	}
+	for _, a := range n.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func htmlAttr(n *html.Node, key string) string {
+	val, _ := lookupHTMLAttr(n, key)
+	return val
+}
+
+func hasHTMLAttr(n *html.Node, key string) bool {
+	_, ok := lookupHTMLAttr(n, key)
+	return ok
+}
+
+// htmlText returns the text within a node, with block-level elements on separate lines and other whitespace collapsed.
+func htmlText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+		case html.ElementNode:
+			if n.DataAtom == atom.Br || n.DataAtom == atom.P || n.DataAtom == atom.Li || n.DataAtom == atom.Div {
+				b.WriteString("\n")
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+
+	if n.DataAtom == atom.Script {
+		return b.String()
+	}
+
+	var lines []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
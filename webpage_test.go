@@ -0,0 +1,99 @@
+package mela_test
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jphastings/mela-recipes"
+)
+
+func TestParseHTML(t *testing.T) {
+	type test struct {
+		fixture    string
+		want       mela.Recipe
+		wantImages int
+	}
+
+	tests := []test{
+		{"jsonld", mela.Recipe{
+			ID:           "https://example.com/shallot-tarte-tatin",
+			Title:        "Shallot tarte tatin",
+			Link:         "https://example.com/shallot-tarte-tatin",
+			Text:         "A savoury upside-down tart.",
+			Ingredients:  "500g banana shallots\n25g butter\n1 sheet puff pastry",
+			Instructions: "# Shallots\nMelt the butter in an ovenproof pan.\nCaramelise the shallots for 20 minutes.\n# Pastry\nTop with the pastry and bake at 200C for 40 minutes.",
+			Categories:   []string{"Main", "Vegetarian"},
+			Yield:        "6 slices",
+			PrepTime:     "20 mins",
+			CookTime:     "1 hr",
+			TotalTime:    "1 hr 20 mins",
+			Nutrition:    "Calories: 320 kcal\nFat: 18 g",
+		}, 1},
+		{"microdata", mela.Recipe{
+			ID:           "https://example.org/lemon-drizzle",
+			Title:        "Lemon drizzle cake",
+			Link:         "https://example.org/lemon-drizzle",
+			Text:         "A classic loaf cake.",
+			Ingredients:  "225g butter\n225g caster sugar\n2 lemons",
+			Instructions: "Cream the butter and sugar.\nBake for 45 minutes.",
+			Categories:   []string{"Baking"},
+			Yield:        "1 loaf (10 slices)",
+			PrepTime:     "15 mins",
+			CookTime:     "45 mins",
+		}, 1},
+		{"microdata-book", mela.Recipe{
+			ID:           "urn:isbn:9780714863603#pages=42",
+			Title:        "Green shakshuka",
+			Link:         "Fresh & Easy",
+			Ingredients:  "200g spinach\n4 eggs",
+			Instructions: "Wilt the spinach.\nCrack in the eggs and cook for 6 minutes.",
+			Categories:   []string{},
+		}, 0},
+		{"microdata-nested", mela.Recipe{
+			Title:        "Flapjacks",
+			Ingredients:  "250g oats\n125g butter\n125g golden syrup",
+			Instructions: "Melt the butter and syrup, stir in the oats and bake for 20 minutes.",
+			Categories:   []string{},
+		}, 0},
+		{"rdfa", mela.Recipe{
+			Title:        "Pea soup",
+			Ingredients:  "500g frozen peas\n1 onion",
+			Instructions: "Soften the onion.\nAdd the peas and simmer for 10 minutes.",
+			Categories:   []string{},
+			Yield:        "4 servings",
+			TotalTime:    "30 mins",
+		}, 0},
+	}
+
+	for _, test := range tests {
+		f, err := os.Open("fixtures/html/" + test.fixture + ".html")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := mela.ParseHTML(f)
+		f.Close()
+		if err != nil {
+			t.Errorf("Unable to parse %s fixture: %v", test.fixture, err)
+			continue
+		}
+
+		if len(got.Images) != test.wantImages {
+			t.Errorf("Incorrect number of images for %s: want = %d, got = %d", test.fixture, test.wantImages, len(got.Images))
+		}
+		got.Images = nil
+
+		if !reflect.DeepEqual(&test.want, got) {
+			t.Errorf("Incorrect recipe for %s:\nwant = %#v\ngot  = %#v", test.fixture, &test.want, got)
+		}
+	}
+}
+
+func TestParseHTML_NoRecipe(t *testing.T) {
+	_, err := mela.ParseHTML(strings.NewReader(`<html><body><p itemscope itemtype="https://schema.org/Person"><span itemprop="name">Nobody</span></p></body></html>`))
+	if err != mela.ErrNoRecipeFound {
+		t.Errorf("Incorrect error: want = %v, got = %v", mela.ErrNoRecipeFound, err)
+	}
+}
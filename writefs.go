@@ -0,0 +1,218 @@
+package mela
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WriteFS is a filesystem that recipes can be saved to, as well as read from. Names are slash-separated paths, as with
+// fs.FS.
+type WriteFS interface {
+	fs.FS
+	// MkdirAll creates the named directory, and any parents it needs.
+	MkdirAll(name string, perm fs.FileMode) error
+	// OpenFile opens the named file for writing, using the os.O_* flags given (as with os.OpenFile). Everything written
+	// must be stored by the time the file is closed.
+	OpenFile(name string, flag int, perm fs.FileMode) (io.WriteCloser, error)
+}
+
+// DirFS is a WriteFS for the directory tree rooted at the given directory on disk.
+type DirFS string
+
+func (dir DirFS) path(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(string(dir), filepath.FromSlash(name)), nil
+}
+
+func (dir DirFS) Open(name string) (fs.File, error) {
+	return os.DirFS(string(dir)).Open(name)
+}
+
+func (dir DirFS) MkdirAll(name string, perm fs.FileMode) error {
+	p, err := dir.path(name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(p, perm)
+}
+
+func (dir DirFS) OpenFile(name string, flag int, perm fs.FileMode) (io.WriteCloser, error) {
+	p, err := dir.path(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(p, flag, perm)
+}
+
+// MemFS is an in-memory WriteFS, eg. for tests. It's safe for concurrent use. Files opened without os.O_APPEND are
+// replaced when they're closed.
+type MemFS struct {
+	mu    sync.RWMutex
+	files map[string]*memEntry
+}
+
+// memEntry is a file or directory in a MemFS. Its data is never changed once stored, only replaced.
+type memEntry struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// NewMemFS creates an empty in-memory filesystem.
+func NewMemFS() *MemFS {
+	return &MemFS{files: map[string]*memEntry{".": {mode: fs.ModeDir | 0o755}}}
+}
+
+func (m *MemFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	info := memFileInfo{name: path.Base(name), memEntry: e}
+	if !e.mode.IsDir() {
+		return &memReader{Reader: bytes.NewReader(e.data), info: info}, nil
+	}
+
+	d := &memDir{info: info}
+	for p, child := range m.files {
+		if p != "." && path.Dir(p) == name {
+			d.entries = append(d.entries, fs.FileInfoToDirEntry(memFileInfo{name: path.Base(p), memEntry: child}))
+		}
+	}
+	sort.Slice(d.entries, func(i, j int) bool { return d.entries[i].Name() < d.entries[j].Name() })
+	return d, nil
+}
+
+func (m *MemFS) MkdirAll(name string, perm fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for dir := name; dir != "."; dir = path.Dir(dir) {
+		if e, ok := m.files[dir]; ok {
+			if !e.mode.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+			}
+			continue
+		}
+		m.files[dir] = &memEntry{mode: fs.ModeDir | perm, modTime: time.Now()}
+	}
+	return nil
+}
+
+func (m *MemFS) OpenFile(name string, flag int, perm fs.FileMode) (io.WriteCloser, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	// The write lock is held so that the name can be claimed, as with os.O_EXCL, before anyone else checks for it
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if dir, ok := m.files[path.Dir(name)]; !ok || !dir.mode.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	w := &memFile{fs: m, name: name, perm: perm}
+	existing, ok := m.files[name]
+	switch {
+	case ok && existing.mode.IsDir():
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+	case ok && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	case !ok && flag&os.O_CREATE == 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	case !ok:
+		m.files[name] = &memEntry{mode: perm, modTime: time.Now()}
+	case flag&os.O_APPEND != 0:
+		w.Write(existing.data)
+	}
+	return w, nil
+}
+
+// memFile is a file being written to a MemFS, which is stored when it's closed.
+type memFile struct {
+	bytes.Buffer
+	fs     *MemFS
+	name   string
+	perm   fs.FileMode
+	closed bool
+}
+
+func (f *memFile) Close() error {
+	if f.closed {
+		return fs.ErrClosed
+	}
+	f.closed = true
+
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	f.fs.files[f.name] = &memEntry{data: bytes.Clone(f.Bytes()), mode: f.perm, modTime: time.Now()}
+	return nil
+}
+
+type memFileInfo struct {
+	name string
+	*memEntry
+}
+
+func (fi memFileInfo) Name() string       { return fi.name }
+func (fi memFileInfo) Size() int64        { return int64(len(fi.data)) }
+func (fi memFileInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi memFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi memFileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi memFileInfo) Sys() any           { return nil }
+
+// memReader is a file in a MemFS opened for reading.
+type memReader struct {
+	*bytes.Reader
+	info memFileInfo
+}
+
+func (f *memReader) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memReader) Close() error               { return nil }
+
+// memDir is a directory in a MemFS opened for reading.
+type memDir struct {
+	info    memFileInfo
+	entries []fs.DirEntry
+	read    int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *memDir) Close() error               { return nil }
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.read:]
+	if n <= 0 {
+		d.read = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	d.read += n
+	return rest[:n], nil
+}
@@ -0,0 +1,174 @@
+package mela
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Yield is a structured form of a recipe's yield, eg. "Makes 24 cookies" or "Serves 4–6".
+type Yield struct {
+	Min float64
+	// Max is equal to Min when the yield isn't a range
+	Max float64
+	// Unit is the noun being counted, "servings" when none is given or when people are being served
+	Unit string
+	// Remainder holds any extra detail, eg. "12 slices" from "1 loaf (12 slices)"
+	Remainder string
+}
+
+var ErrInvalidYield = errors.New("the given string does not contain a yield quantity")
+
+const yieldServings = "servings"
+
+// quantityNumber matches mixed numbers ("1 1/2", "1½"), fractions, decimals (with a point or comma) and numbers with
+// thousands separators ("1,000").
+const quantityNumber = `\d+\s+\d+\s*/\s*\d+|\d*\s*[½⅓⅔¼¾⅛]|\d{1,3}(?:,\d{3})+\b(?:\.\d+)?|\d+(?:[.,]\d+)?(?:\s*/\s*\d+)?`
+
+var yieldNumber = `(` + quantityNumber + `)`
+var yieldMatcher = regexp.MustCompile(`(?i)^\s*(?:(serves|feeds|makes|yields?|for|servings?|portions?)\s*:?\s*)?(?:about|approx\.?|approximately)?\s*` +
+	yieldNumber + `(?:\s*(?:-|–|—|to|or)\s*` + yieldNumber + `)?\s*(.*)$`)
+var thousandsSeparated = regexp.MustCompile(`^\d{1,3}(?:,\d{3})+(?:\.\d+)?$`)
+var yieldRemainderSplitter = regexp.MustCompile(`\s*[(,;]`)
+
+var vulgarFractions = map[rune]float64{'½': 0.5, '⅓': 1.0 / 3, '⅔': 2.0 / 3, '¼': 0.25, '¾': 0.75, '⅛': 0.125}
+
+var servingsSynonyms = map[string]bool{
+	"": true, "serving": true, "servings": true, "portion": true, "portions": true,
+	"people": true, "person": true, "persons": true, "adults": true, "guests": true,
+}
+
+// Yield parses the yield as written in a recipe into its structured form.
+func (pc PeopleCount) Yield() (*Yield, error) {
+	return ParseYield(string(pc))
+}
+
+// ParseYield interprets free text yields like "Serves 4–6", "Makes 24 cookies" or "1 loaf (12 slices)".
+func ParseYield(str string) (*Yield, error) {
+	m := yieldMatcher.FindStringSubmatch(strings.TrimSpace(str))
+	if m == nil {
+		return nil, ErrInvalidYield
+	}
+
+	min, err := parseYieldNumber(m[2])
+	if err != nil {
+		return nil, err
+	}
+	max := min
+	if m[3] != "" {
+		if max, err = parseYieldNumber(m[3]); err != nil {
+			return nil, err
+		}
+	}
+	if max < min {
+		min, max = max, min
+	}
+
+	y := &Yield{Min: min, Max: max}
+
+	rest := strings.TrimSpace(m[4])
+	if loc := yieldRemainderSplitter.FindStringIndex(rest); loc != nil {
+		y.Remainder = strings.TrimSpace(strings.Trim(strings.TrimSpace(rest[loc[0]:]), "(),;"))
+		rest = strings.TrimSpace(rest[:loc[0]])
+	}
+
+	y.Unit = strings.TrimSuffix(rest, ".")
+	if servingsSynonyms[strings.ToLower(y.Unit)] {
+		y.Unit = yieldServings
+	}
+
+	// "Serves" and "feeds" always count people, so anything after the number is extra detail, eg. "as a starter"
+	if prefix := strings.ToLower(m[1]); (prefix == "serves" || prefix == "feeds") && y.Unit != yieldServings {
+		detail := y.Unit
+		if first, after, _ := strings.Cut(detail, " "); servingsSynonyms[strings.ToLower(first)] {
+			detail = strings.TrimSpace(after)
+		}
+		if y.Remainder != "" {
+			detail += ", " + y.Remainder
+		}
+		y.Unit, y.Remainder = yieldServings, detail
+	}
+
+	return y, nil
+}
+
+func parseYieldNumber(str string) (float64, error) {
+	str = strings.TrimSpace(str)
+	if thousandsSeparated.MatchString(str) {
+		str = strings.ReplaceAll(str, ",", "")
+	} else {
+		str = strings.ReplaceAll(str, ",", ".")
+	}
+
+	for r, frac := range vulgarFractions {
+		if whole, found := strings.CutSuffix(str, string(r)); found {
+			whole = strings.TrimSpace(whole)
+			if whole == "" {
+				return frac, nil
+			}
+			n, err := strconv.ParseFloat(whole, 64)
+			return n + frac, err
+		}
+	}
+
+	if num, den, found := strings.Cut(str, "/"); found {
+		// eg. "1 1/2"
+		whole := 0.0
+		if parts := strings.Fields(num); len(parts) == 2 {
+			w, err := strconv.ParseFloat(parts[0], 64)
+			if err != nil {
+				return 0, err
+			}
+			whole, num = w, parts[1]
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+		if err != nil {
+			return 0, err
+		}
+		d, err := strconv.ParseFloat(strings.TrimSpace(den), 64)
+		if err != nil || d == 0 {
+			return 0, ErrInvalidYield
+		}
+		return whole + n/d, nil
+	}
+
+	return strconv.ParseFloat(str, 64)
+}
+
+// IsRange is true when the yield has differing minimum and maximum quantities.
+func (y *Yield) IsRange() bool {
+	return y.Min != y.Max
+}
+
+// Format writes the yield back out in a normalised form, eg. "4–6 servings" or "1 loaf (12 slices)".
+func (y *Yield) Format() PeopleCount {
+	return PeopleCount(y.String())
+}
+
+func (y *Yield) String() string {
+	out := formatQuantity(y.Min)
+	if y.IsRange() {
+		out += "–" + formatQuantity(y.Max)
+	}
+
+	unit := y.Unit
+	if unit == "" {
+		unit = yieldServings
+	}
+	if unit == yieldServings && !y.IsRange() && y.Min == 1 {
+		unit = "serving"
+	}
+	out += " " + unit
+
+	if y.Remainder != "" {
+		out += fmt.Sprintf(" (%s)", y.Remainder)
+	}
+
+	return out
+}
+
+func formatQuantity(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
@@ -0,0 +1,62 @@
+package mela_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/jphastings/mela-recipes"
+)
+
+func TestParseYield(t *testing.T) {
+	type test struct {
+		input      string
+		want       *Yield
+		wantFormat PeopleCount
+	}
+
+	tests := []test{
+		{"4", &Yield{Min: 4, Max: 4, Unit: "servings"}, "4 servings"},
+		{"1", &Yield{Min: 1, Max: 1, Unit: "servings"}, "1 serving"},
+		{"Serves 4–6", &Yield{Min: 4, Max: 6, Unit: "servings"}, "4–6 servings"},
+		{"serves 4 to 6", &Yield{Min: 4, Max: 6, Unit: "servings"}, "4–6 servings"},
+		{"Servings: 8", &Yield{Min: 8, Max: 8, Unit: "servings"}, "8 servings"},
+		{"4 people", &Yield{Min: 4, Max: 4, Unit: "servings"}, "4 servings"},
+		{"Makes 24 cookies", &Yield{Min: 24, Max: 24, Unit: "cookies"}, "24 cookies"},
+		{"1 loaf (12 slices)", &Yield{Min: 1, Max: 1, Unit: "loaf", Remainder: "12 slices"}, "1 loaf (12 slices)"},
+		{"Makes about 2 dozen small biscuits, depending on cutter", &Yield{Min: 2, Max: 2, Unit: "dozen small biscuits", Remainder: "depending on cutter"}, "2 dozen small biscuits (depending on cutter)"},
+		{"1½ litres", &Yield{Min: 1.5, Max: 1.5, Unit: "litres"}, "1.5 litres"},
+		{"1/2 cup", &Yield{Min: 0.5, Max: 0.5, Unit: "cup"}, "0.5 cup"},
+		{"Serves 1 1/2", &Yield{Min: 1.5, Max: 1.5, Unit: "servings"}, "1.5 servings"},
+		{"Makes 1,000 cookies", &Yield{Min: 1000, Max: 1000, Unit: "cookies"}, "1000 cookies"},
+		{"1,5 litres", &Yield{Min: 1.5, Max: 1.5, Unit: "litres"}, "1.5 litres"},
+		{"Serves 8-10 as a starter", &Yield{Min: 8, Max: 10, Unit: "servings", Remainder: "as a starter"}, "8–10 servings (as a starter)"},
+		{"Feeds 4 people as a main", &Yield{Min: 4, Max: 4, Unit: "servings", Remainder: "as a main"}, "4 servings (as a main)"},
+		{"6-4 pancakes", &Yield{Min: 4, Max: 6, Unit: "pancakes"}, "4–6 pancakes"},
+
+		{"", nil, ""},
+		{"Lots", nil, ""},
+		{"Serves a crowd", nil, ""},
+	}
+
+	for _, test := range tests {
+		got, err := PeopleCount(test.input).Yield()
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("Expected an error for '%s', got = %#v", test.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for '%s': %v", test.input, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(test.want, got) {
+			t.Errorf("Incorrect yield for '%s': want = %#v, got = %#v", test.input, test.want, got)
+		}
+
+		if format := got.Format(); format != test.wantFormat {
+			t.Errorf("Incorrect format for '%s': want = %s, got = %s", test.input, test.wantFormat, format)
+		}
+	}
+}